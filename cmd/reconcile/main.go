@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"one-to-one/internal/config"
+	"one-to-one/internal/db"
+	"one-to-one/internal/services/user"
+	"os"
+)
+
+// reconcile repairs the reporting lines stored before they were kept consistent: it clears
+// self-references, unknown managers and cycles, and rebuilds every manager's reportees from the
+// reportsTo of their reports. The repairs are printed as JSON.
+//
+//	go run cmd/reconcile/main.go -dry-run
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the repairs without making them")
+	flag.Parse()
+
+	if err := config.LoadConfig(); err != nil {
+		log.Fatal("Error loading config: ", err)
+	}
+
+	db.ConnectToMongoDB()
+	defer db.DisconnectFromMongoDB()
+
+	report, err := user.NewUserRepository().ReconcileReportingLines(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatal("Error reconciling reporting lines: ", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal("Error writing report: ", err)
+	}
+}
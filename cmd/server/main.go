@@ -4,8 +4,11 @@ import (
 	"log"
 	"one-to-one/internal/config"
 	"one-to-one/internal/db"
+	"one-to-one/internal/mailer"
+	"one-to-one/internal/middleware"
 	"one-to-one/internal/pusher"
 	"one-to-one/internal/routes"
+	"one-to-one/internal/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -26,15 +29,25 @@ func main() {
 		log.Fatal("Error loading config: ", err)
 	}
 
+	if err := middleware.InitSigningKeys(); err != nil {
+		log.Fatal("Error loading signing keys: ", err)
+	}
+
 	db.ConnectToMongoDB()
 	pusher.Init()
+	mailer.Init()
+	storage.Init()
 	defer db.DisconnectFromMongoDB()
 
 	router := gin.Default()
+	if err := router.SetTrustedProxies(config.AppConfig().App.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies: ", err)
+	}
+	router.TrustedPlatform = config.AppConfig().App.ClientIPHeader
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.TenantHeader},
 		ExposeHeaders:    []string{"Content-Length", "Authorization"},
 		AllowCredentials: true,
 		AllowWildcard:    true,
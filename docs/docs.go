@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/one-to-one/create": {
-            "post": {
-                "description": "Create a new weekly report",
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List audit log entries, most recent first",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,29 +30,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "audit"
                 ],
-                "summary": "Create a new weekly report",
+                "summary": "Get audit log entries",
                 "parameters": [
                     {
-                        "description": "Weekly report object to be created",
-                        "name": "report",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/one_to_one.CreateWeeklyReportRequest"
-                        }
+                        "type": "string",
+                        "description": "Only entries by this user",
+                        "name": "actorId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries about this user",
+                        "name": "subjectId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries with this action, e.g. impersonation.request",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries, 100 by default",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Weekly report created successfully",
+                    "200": {
+                        "description": "Audit log entries",
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/audit.EntryResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request format or parameters",
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -63,44 +93,68 @@ const docTemplate = `{
                 }
             }
         },
-        "/one-to-one/report-to": {
+        "/auth/oidc/callback": {
             "get": {
-                "description": "Get a weekly report by week and year for a reportTo",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Exchange the authorization code returned by the identity provider for the app tokens. Unknown users are provisioned from the ID token claims.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "auth"
                 ],
-                "summary": "Get a weekly report by week and year for a reportTo",
+                "summary": "Finish OIDC login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Week number",
-                        "name": "week",
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Year",
-                        "name": "year",
+                        "type": "string",
+                        "description": "State returned by the identity provider",
+                        "name": "state",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Weekly report",
+                        "description": "User logged in successfully",
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "The identity provider did not ask for a second factor, a TOTP code is required to finish logging in",
+                        "schema": {
+                            "$ref": "#/definitions/user.MFAChallengeResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format or parameters",
+                        "description": "Invalid request format, or invalid or expired state",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Login rejected by the identity provider",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "No verified email address, or account has been deactivated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "OIDC login is not configured, or not available for this organisation",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -116,27 +170,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/one-to-one/report-to/all": {
+        "/auth/oidc/login": {
             "get": {
-                "description": "Get all weekly reports",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Redirect to the corporate identity provider using the authorization code flow with PKCE. Only the first organisation logs in with the identity provider.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "auth"
                 ],
-                "summary": "Get all weekly reports for a reportTo",
+                "summary": "Start OIDC login",
                 "responses": {
-                    "200": {
-                        "description": "List of weekly reports",
+                    "302": {
+                        "description": "Redirect to the identity provider"
+                    },
+                    "404": {
+                        "description": "OIDC login is not configured, or not available for this organisation",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -145,43 +197,48 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/one-to-one/report-to/update": {
-            "put": {
-                "description": "Update a weekly report",
-                "consumes": [
-                    "application/json"
+        "/directory/sync": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Reconcile the users of the LDAP directory into the user collection. Called by the Vercel cron with CRON_SECRET as bearer token. Only reports the changes when LDAP_SYNC_DRY_RUN is set. Syncs that find no users, or would deactivate more than LDAP_MAX_DEACTIVATIONS, are refused.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
-                ],
-                "summary": "Update a weekly report for a reportTo",
-                "parameters": [
-                    {
-                        "description": "Weekly report object to be updated",
-                        "name": "report",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/one_to_one.UpdateWeeklyReportRequest"
-                        }
-                    }
+                    "directory"
                 ],
+                "summary": "Run the scheduled directory sync",
                 "responses": {
                     "200": {
-                        "description": "Weekly report updated successfully",
+                        "description": "Directory synced successfully",
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            "$ref": "#/definitions/directory.SyncReport"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request format or parameters",
+                    "401": {
+                        "description": "Invalid cron secret",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Directory sync is not configured",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -193,48 +250,61 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "502": {
+                        "description": "Directory unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            }
-        },
-        "/one-to-one/reportee": {
-            "get": {
-                "description": "Get a weekly report by week and year for a reportee",
-                "consumes": [
-                    "application/json"
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Reconcile the users of the LDAP directory into the user collection: create, update, deactivate and reactivate users and rebuild their reporting lines. A dry run only reports the changes. Syncs that find no users, or would deactivate more than LDAP_MAX_DEACTIVATIONS, are refused.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "directory"
                 ],
-                "summary": "Get a weekly report by week and year for a reportee",
+                "summary": "Sync the directory",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Week number",
-                        "name": "week",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Year",
-                        "name": "year",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Only report the changes",
+                        "name": "dryRun",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Weekly report",
+                        "description": "Directory synced successfully",
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            "$ref": "#/definitions/directory.SyncReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format or parameters",
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Directory sync is not configured",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -246,13 +316,25 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "502": {
+                        "description": "Directory unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/one-to-one/reportee/all": {
+        "/invitation": {
             "get": {
-                "description": "Get all weekly reports",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the invitations sent by the current user, most recent first",
                 "consumes": [
                     "application/json"
                 ],
@@ -260,16 +342,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "invitations"
                 ],
-                "summary": "Get all weekly reports for a reportee",
+                "summary": "Get sent invitations",
                 "responses": {
                     "200": {
-                        "description": "List of weekly reports",
+                        "description": "Invitations retrieved successfully",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                                "$ref": "#/definitions/invitation.InvitationResponse"
                             }
                         }
                     },
@@ -281,11 +363,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/one-to-one/reportee/update": {
-            "put": {
-                "description": "Update a weekly report",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Email an invitation to join as a reportee of the current user. Inviting the same email again replaces the previous invitation.",
                 "consumes": [
                     "application/json"
                 ],
@@ -293,25 +378,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "one-to-one"
+                    "invitations"
                 ],
-                "summary": "Update a weekly report for a reportee",
+                "summary": "Invite a reportee",
                 "parameters": [
                     {
-                        "description": "Weekly report object to be updated",
-                        "name": "report",
+                        "description": "Email and optional name of the invitee",
+                        "name": "invitation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.UpdateWeeklyReportRequest"
+                            "$ref": "#/definitions/invitation.CreateInvitationRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Weekly report updated successfully",
+                    "201": {
+                        "description": "Invitation sent successfully",
                         "schema": {
-                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            "$ref": "#/definitions/invitation.InvitationResponse"
                         }
                     },
                     "400": {
@@ -321,37 +406,18 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/user/all": {
-            "get": {
-                "description": "Get all users",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "users"
-                ],
-                "summary": "Get all users",
-                "responses": {
-                    "200": {
-                        "description": "Users retrieved successfully",
+                    },
+                    "409": {
+                        "description": "A user with this email already exists",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/user.UserResponse"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -364,9 +430,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/create": {
+        "/invitation/accept": {
             "post": {
-                "description": "Create a new user",
+                "description": "Create an account from an invitation. The account reports to the manager who sent the invitation, its email is verified, and the user is logged in.",
                 "consumes": [
                     "application/json"
                 ],
@@ -374,29 +440,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
                 ],
-                "summary": "Create a new user",
+                "summary": "Accept an invitation",
                 "parameters": [
                     {
-                        "description": "User object to be created",
-                        "name": "user",
+                        "description": "Invitation token and account details",
+                        "name": "invitation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.CreateUserRequest"
+                            "$ref": "#/definitions/invitation.AcceptInvitationRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "User created successfully",
+                        "description": "Invitation accepted successfully",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "$ref": "#/definitions/user.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format or parameters",
+                        "description": "Invalid request format, invalid or expired invitation, or password rejected by the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "A user with this email already exists, or the inviter can no longer be the new user's manager",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -412,14 +485,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/current": {
-            "get": {
+        "/invitation/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get current user",
+                "description": "Withdraw a pending invitation sent by the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -427,14 +500,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "invitations"
+                ],
+                "summary": "Revoke an invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get current user",
                 "responses": {
                     "200": {
-                        "description": "User retrieved successfully",
+                        "description": "Invitation revoked successfully",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid invitation ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Invitation not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -447,9 +544,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/email/{email}": {
-            "get": {
-                "description": "Get user by email",
+        "/one-to-one/create": {
+            "post": {
+                "description": "Create a new weekly report",
                 "consumes": [
                     "application/json"
                 ],
@@ -457,23 +554,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "one-to-one"
                 ],
-                "summary": "Get user by email",
+                "summary": "Create a new weekly report",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
+                        "description": "Weekly report object to be created",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/one_to_one.CreateWeeklyReportRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User retrieved successfully",
+                    "201": {
+                        "description": "Weekly report created successfully",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
                         }
                     },
                     "400": {
@@ -483,8 +582,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "409": {
+                        "description": "The user has no manager to report to",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -500,9 +599,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/login": {
-            "post": {
-                "description": "Login user",
+        "/one-to-one/report-to": {
+            "get": {
+                "description": "Get a weekly report by week and year for a reportTo",
                 "consumes": [
                     "application/json"
                 ],
@@ -510,25 +609,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "one-to-one"
                 ],
-                "summary": "Login user",
+                "summary": "Get a weekly report by week and year for a reportTo",
                 "parameters": [
                     {
-                        "description": "User login credentials",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.LoginRequest"
-                        }
+                        "type": "integer",
+                        "description": "Week number",
+                        "name": "week",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User logged in successfully",
+                        "description": "Weekly report",
                         "schema": {
-                            "$ref": "#/definitions/user.LoginResponse"
+                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
                         }
                     },
                     "400": {
@@ -538,12 +642,38 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid credentials",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                }
+            }
+        },
+        "/one-to-one/report-to/all": {
+            "get": {
+                "description": "Get all weekly reports",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "one-to-one"
+                ],
+                "summary": "Get all weekly reports for a reportTo",
+                "responses": {
+                    "200": {
+                        "description": "List of weekly reports",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal server error",
@@ -555,14 +685,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/reportee/add": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Add reportee",
+        "/one-to-one/report-to/update": {
+            "put": {
+                "description": "Update a weekly report",
                 "consumes": [
                     "application/json"
                 ],
@@ -570,25 +695,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "one-to-one"
                 ],
-                "summary": "Add reportee",
+                "summary": "Update a weekly report for a reportTo",
                 "parameters": [
                     {
-                        "description": "Reportee object to be added",
-                        "name": "reportee",
+                        "description": "Weekly report object to be updated",
+                        "name": "report",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.AddReporteeRequest"
+                            "$ref": "#/definitions/one_to_one.UpdateWeeklyReportRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reportee added successfully",
+                        "description": "Weekly report updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
                         }
                     },
                     "400": {
@@ -598,13 +723,6 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Reportee not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -615,14 +733,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/reportee/remove": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Remove reportee",
+        "/one-to-one/reportee": {
+            "get": {
+                "description": "Get a weekly report by week and year for a reportee",
                 "consumes": [
                     "application/json"
                 ],
@@ -630,25 +743,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "one-to-one"
                 ],
-                "summary": "Remove reportee",
+                "summary": "Get a weekly report by week and year for a reportee",
                 "parameters": [
                     {
-                        "description": "Reportee object to be removed",
-                        "name": "reportee",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.RemoveReporteeRequest"
-                        }
+                        "type": "integer",
+                        "description": "Week number",
+                        "name": "week",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reportee removed successfully",
+                        "description": "Weekly report",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
                         }
                     },
                     "400": {
@@ -658,12 +776,38 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Reportee not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                }
+            }
+        },
+        "/one-to-one/reportee/all": {
+            "get": {
+                "description": "Get all weekly reports",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "one-to-one"
+                ],
+                "summary": "Get all weekly reports for a reportee",
+                "responses": {
+                    "200": {
+                        "description": "List of weekly reports",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal server error",
@@ -675,14 +819,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/reports-to/add": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Add reports to user",
+        "/one-to-one/reportee/update": {
+            "put": {
+                "description": "Update a weekly report",
                 "consumes": [
                     "application/json"
                 ],
@@ -690,25 +829,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "one-to-one"
                 ],
-                "summary": "Add reports to user",
+                "summary": "Update a weekly report for a reportee",
                 "parameters": [
                     {
-                        "description": "Report object to be added",
+                        "description": "Weekly report object to be updated",
                         "name": "report",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.AddReportsToRequest"
+                            "$ref": "#/definitions/one_to_one.UpdateWeeklyReportRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Report added successfully",
+                        "description": "Weekly report updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/user.UserResponse"
+                            "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
                         }
                     },
                     "400": {
@@ -718,13 +857,6 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -734,209 +866,4288 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "one_to_one.Agenda": {
-            "type": "object",
-            "required": [
-                "label"
+        },
+        "/one-to-one/skip-level/all": {
+            "get": {
+                "description": "Get the weekly reports of the direct and indirect reportees of the current user. Reports written to another manager only include the items their reportee shared with skip-level managers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "one-to-one"
+                ],
+                "summary": "Get the weekly reports of everyone below a manager",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only return the reports of this reportee",
+                        "name": "reportee",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of weekly reports",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/one_to_one.WeeklyReportResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid reportee ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The reportee is not below the current user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/organisation": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the name and settings of the organisation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organisation"
+                ],
+                "summary": "Get the organisation",
+                "responses": {
+                    "200": {
+                        "description": "Organisation retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/organisation.OrganisationResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "The organisation has not been set up",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename the organisation, set the default manager new users report to, whether every member has to use two-factor authentication and whether anyone may sign up. Without a default manager, new users start out unassigned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organisation"
+                ],
+                "summary": "Update the organisation",
+                "parameters": [
+                    {
+                        "description": "New name, default manager, two-factor requirement and sign-up setting",
+                        "name": "organisation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/organisation.UpdateOrganisationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Organisation updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/organisation.OrganisationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, or unknown default manager",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "The organisation has not been set up",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/pusher/auth": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign a Pusher subscription to the private channel of the current user. Pusher clients call it with the socket_id and channel_name form fields and expect the signature as the whole response.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Authorize a notification channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pusher socket ID",
+                        "name": "socket_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel to subscribe to, private-user-\u003cid\u003e of the current user",
+                        "name": "channel_name",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Channel authorization",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The channel belongs to another user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Notifications are not configured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reporting-line/requests": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the change requests the current user made, has to answer or is the subject of, the most recent first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "List reporting line change requests",
+                "responses": {
+                    "200": {
+                        "description": "Change requests retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reporting-line/requests/pending": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every change request of the organisation waiting for an answer. Admin and HR only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "List pending reporting line change requests",
+                "responses": {
+                    "200": {
+                        "description": "Change requests retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reporting-line/requests/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accept a change request and make the change. Only the approver of the request, an admin or HR can accept it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Accept a reporting line change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Change request accepted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid change request ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Change request not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The request was already answered, is out of date, or would make a user their own manager or close a cycle",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reporting-line/requests/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraw a change request the current user made",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Cancel a reporting line change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Change request cancelled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid change request ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Change request not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The request was already answered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reporting-line/requests/{id}/decline": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Decline a change request. Only the approver of the request, an admin or HR can decline it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Decline a reporting line change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Change request declined successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid change request ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Change request not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The request was already answered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ServiceProviderConfig": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Describe the SCIM features supported by this server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Get the SCIM service provider configuration",
+                "responses": {
+                    "200": {
+                        "description": "Service provider configuration",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ServiceProviderConfig"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List users for a SCIM client. The only supported filters are equality tests on id, userName, emails.value, externalId and active.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter, e.g. userName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "1-based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of results",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported filter",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Provision a user. The userName is the email address of the account, which is treated as verified. The enterprise manager attribute sets who the user reports to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Create a user",
+                "parameters": [
+                    {
+                        "description": "User to provision",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A user with this userName already exists",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a user by their SCIM id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Get a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the attributes of a user. Leaving out the enterprise manager attribute removes the user's manager.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Replace a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New attributes of the user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User replaced successfully",
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A user with this userName already exists",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deactivate a user and log them out everywhere. Users are never deleted, so that their one-to-ones stay attributed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Deactivate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User deactivated successfully"
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply PATCH operations to a user. Setting active to false deactivates the user and logs them out everywhere.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Operations to apply",
+                        "name": "operations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/scim.PatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/scim.UserResource"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid operation",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid bearer token",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A user with this userName already exists",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/setup": {
+            "get": {
+                "description": "Report whether the first-run setup still has to be run",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "Get the setup status",
+                "responses": {
+                    "200": {
+                        "description": "Setup status retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/setup.SetupStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create the first organisation and its first admin, who is logged in. Users and reports stored before organisations existed join it. The setup can only be run once, and outside local it requires the SETUP_TOKEN.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "Run the first-run setup",
+                "parameters": [
+                    {
+                        "description": "Organisation and first admin",
+                        "name": "setup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/setup.SetupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Setup completed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters, or password rejected by the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid setup token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The setup is disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The organisation has already been set up, the slug is taken, or a user with this email already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/setup/organisation": {
+            "post": {
+                "description": "Create another organisation and its first admin, who is logged in. Requires the SETUP_TOKEN, also in local.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "Create an organisation",
+                "parameters": [
+                    {
+                        "description": "Organisation and first admin",
+                        "name": "setup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/setup.SetupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Organisation created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters, or password rejected by the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid setup token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Creating organisations is disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The slug is taken",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/all": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "responses": {
+                    "200": {
+                        "description": "Users retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.UserResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/create": {
+            "post": {
+                "description": "Sign up to the organisation named by the Tenant header, the first organisation when left out. Organisations other than the first only take new members through invitations, unless they allow sign-ups.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User object to be created",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The organisation does not allow sign-ups",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/current": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "User retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the profile of the current user. Fields left out are kept; optional fields are cleared with an empty string.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update current user",
+                "parameters": [
+                    {
+                        "description": "Profile fields to change",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/current/avatar": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the profile picture of the current user. The image is cropped to a square and stored in every size of AvatarSizes.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Upload avatar",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "JPEG, PNG, GIF or WebP image",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Avatar uploaded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing avatar or image too large in pixels",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Avatar file too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported image type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the profile picture of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete avatar",
+                "responses": {
+                    "200": {
+                        "description": "Avatar deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No avatar to delete",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/verify": {
+            "post": {
+                "description": "Confirm the email address of an account using the token from a verification email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Verify email address",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, or invalid or expired token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/verify/resend": {
+            "post": {
+                "description": "Send a new verification email. The response is the same whether or not the email belongs to an unverified account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification email requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "description": "Login user. Repeated failures lock the account and the client IP address for an increasing amount of time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "User login credentials",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User logged in successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Password accepted, a TOTP code is required to finish logging in",
+                        "schema": {
+                            "$ref": "#/definitions/user.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email or password",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Email address has not been verified or account has been deactivated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login/mfa": {
+            "post": {
+                "description": "Exchange the MFA token from the first login step and a TOTP or recovery code for the app tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Finish two-factor login",
+                "parameters": [
+                    {
+                        "description": "MFA token and code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.MFALoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User logged in successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired MFA token, or invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "End the current session, revoking its access and refresh tokens. A refresh token can be given to end its session as well, for tokens issued before sessions were recorded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Logout user",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke",
+                        "name": "token",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User logged out successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout/all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke every access and refresh token issued to the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Logout user everywhere",
+                "responses": {
+                    "200": {
+                        "description": "User logged out everywhere successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm enrollment with a first TOTP code. The recovery codes are only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Activate two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication enabled",
+                        "schema": {
+                            "$ref": "#/definitions/user.MFARecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, no enrollment in progress or invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn off two-factor authentication for the current user. Not allowed while it is enforced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, not enabled or enforced",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a TOTP secret for the current user. The provisioning URI is meant to be shown as a QR code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Start two-factor enrollment",
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret generated",
+                        "schema": {
+                            "$ref": "#/definitions/user.MFAEnrollmentResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace every recovery code of the current user. The new codes are only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recovery codes regenerated",
+                        "schema": {
+                            "$ref": "#/definitions/user.MFARecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/change": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the password of the current user. Every other session is logged out and new tokens are returned for this one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, wrong current password, or password rejected by the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/forgot": {
+            "post": {
+                "description": "Email a single-use password reset link. The response is the same whether or not the email belongs to an account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/reset": {
+            "post": {
+                "description": "Set a new password using a token from a password reset email. Every existing session of the user is logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, invalid or expired token, or password rejected by the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reportee/add": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ask a user to report to the current user. The change is made once the user, or an admin or HR, accepts it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Add reportee",
+                "parameters": [
+                    {
+                        "description": "Reportee object to be added",
+                        "name": "reportee",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.AddReporteeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Change requested successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Reportee not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The user already reports to the current user, would be their own manager or the change has already been requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reportee/remove": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ask a user who reports to the current user to report to nobody. The change is made once the user, or an admin or HR, accepts it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Remove reportee",
+                "parameters": [
+                    {
+                        "description": "Reportee object to be removed",
+                        "name": "reportee",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RemoveReporteeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Change requested successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Reportee not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The change has already been requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reports-to/add": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ask another user to become the manager of the current user. The change is made once that user, or an admin or HR, accepts it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reporting-lines"
+                ],
+                "summary": "Add reports to user",
+                "parameters": [
+                    {
+                        "description": "Report object to be added",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.AddReportsToRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Change requested successfully",
+                        "schema": {
+                            "$ref": "#/definitions/reportingline.ChangeRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The current user already reports to the user, would be their own manager or the change has already been requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the roles that can be assigned to users and the permissions they grant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List roles",
+                "responses": {
+                    "200": {
+                        "description": "Roles retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.RoleResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the devices the current user is logged in on, most recently used first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List sessions",
+                "responses": {
+                    "200": {
+                        "description": "Sessions retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.SessionResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Log the current user out of one of their sessions. Its tokens stop working immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session revoked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid session ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/token/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or reused refresh token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the personal access tokens of the current user, including revoked and expired ones",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List personal access tokens",
+                "responses": {
+                    "200": {
+                        "description": "Tokens retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.PersonalAccessTokenResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor authentication is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a token for scripts and integrations. The token is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a personal access token",
+                "parameters": [
+                    {
+                        "description": "Token name, scopes and lifetime",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.CreatePersonalAccessTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Token created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.CreatedPersonalAccessTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor authentication is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke one of the current user's personal access tokens. It stops working immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke a personal access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor authentication is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the profile of a user. Fields left out are kept; optional fields are cleared with an empty string. Admin and HR only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Profile fields to change",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue a short-lived token to act as another user, e.g. to reproduce what they see. Responses to requests made with it are flagged, and every request is written to the audit log. Admins cannot be impersonated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Impersonate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for impersonating the user",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ImpersonateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Impersonation token issued",
+                        "schema": {
+                            "$ref": "#/definitions/user.ImpersonationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/managers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the managers a user has reported to and when, the current manager first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get the manager history of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Manager history retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.ManagerHistoryEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/org-chart": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the reporting tree rooted at a user, or in ancestors mode the chain of managers from the user's manager up to the top of the organisation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get the org chart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "tree (default) or ancestors",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of levels below the user in tree mode, 3 by default, or above the user in ancestors mode, all by default. At most 20.",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date (2006-01-02) or time (RFC 3339) to show the reporting lines as they were at, now by default",
+                        "name": "asOf",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Org chart retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.OrgChartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID, mode, depth or date",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/roles": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the roles of a user. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user roles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New roles",
+                        "name": "roles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateRolesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Roles updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear the failed login attempts of a user so they can log in again right away",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unlock a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User unlocked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.EntryResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actorEmail": {
+                    "type": "string"
+                },
+                "actorId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "subjectEmail": {
+                    "type": "string"
+                },
+                "subjectId": {
+                    "type": "string"
+                }
+            }
+        },
+        "directory.ManagerChange": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "directory.SyncReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/directory.UserChange"
+                    }
+                },
+                "deactivated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/directory.UserChange"
+                    }
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "managerChanges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/directory.ManagerChange"
+                    }
+                },
+                "reactivated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/directory.UserChange"
+                    }
+                },
+                "refused": {
+                    "description": "why none of the changes were applied",
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/directory.UserChange"
+                    }
+                },
+                "warnings": {
+                    "description": "entries that were skipped and why",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "directory.UserChange": {
+            "type": "object",
+            "properties": {
+                "dn": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "attributes that changed, for updated users",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "invitation.AcceptInvitationRequest": {
+            "type": "object",
+            "required": [
+                "firstName",
+                "lastName",
+                "password",
+                "token"
+            ],
+            "properties": {
+                "firstName": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "invitation.CreateInvitationRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                }
+            }
+        },
+        "invitation.InvitationResponse": {
+            "type": "object",
+            "properties": {
+                "acceptedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invitedBy": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "one_to_one.Agenda": {
+            "type": "object",
+            "required": [
+                "label"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "skipLevelVisible": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "one_to_one.Challenges": {
+            "type": "object",
+            "required": [
+                "label",
+                "theme"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "skipLevelVisible": {
+                    "type": "boolean"
+                },
+                "theme": {
+                    "type": "string"
+                }
+            }
+        },
+        "one_to_one.CreateWeeklyReportRequest": {
+            "type": "object",
+            "required": [
+                "agendas",
+                "challenges",
+                "goneWell",
+                "week",
+                "wellbeingScores",
+                "year"
+            ],
+            "properties": {
+                "agendas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Agenda"
+                    }
+                },
+                "challenges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Challenges"
+                    }
+                },
+                "goneWell": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.GoneWell"
+                    }
+                },
+                "week": {
+                    "type": "integer"
+                },
+                "wellbeingScores": {
+                    "$ref": "#/definitions/one_to_one.WellbeingScores"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "one_to_one.GoneWell": {
+            "type": "object",
+            "required": [
+                "label",
+                "theme"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "skipLevelVisible": {
+                    "type": "boolean"
+                },
+                "theme": {
+                    "type": "string"
+                }
+            }
+        },
+        "one_to_one.UpdateWeeklyReportRequest": {
+            "type": "object",
+            "required": [
+                "agendas",
+                "challenges",
+                "goneWell",
+                "id",
+                "week",
+                "wellbeingScores",
+                "year"
             ],
             "properties": {
-                "label": {
+                "agendas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Agenda"
+                    }
+                },
+                "challenges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Challenges"
+                    }
+                },
+                "goneWell": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.GoneWell"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "week": {
+                    "type": "integer"
+                },
+                "wellbeingScores": {
+                    "$ref": "#/definitions/one_to_one.WellbeingScores"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "one_to_one.WeeklyReportResponse": {
+            "type": "object",
+            "properties": {
+                "agendas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Agenda"
+                    }
+                },
+                "challenges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.Challenges"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "goneWell": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/one_to_one.GoneWell"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reportee": {
+                    "type": "string"
+                },
+                "reporteeSummary": {
+                    "$ref": "#/definitions/user.UserSummary"
+                },
+                "reportingTo": {
+                    "type": "string"
+                },
+                "reportingToSummary": {
+                    "$ref": "#/definitions/user.UserSummary"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "week": {
+                    "type": "integer"
+                },
+                "wellbeingScores": {
+                    "$ref": "#/definitions/one_to_one.WellbeingScores"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "one_to_one.WellbeingScores": {
+            "type": "object",
+            "required": [
+                "growth",
+                "impactAndProductivity",
+                "wellbeing",
+                "workOverall",
+                "workRelationships"
+            ],
+            "properties": {
+                "growth": {
+                    "type": "integer"
+                },
+                "impactAndProductivity": {
+                    "type": "integer"
+                },
+                "wellbeing": {
+                    "type": "integer"
+                },
+                "workOverall": {
+                    "type": "integer"
+                },
+                "workRelationships": {
+                    "type": "integer"
+                }
+            }
+        },
+        "organisation.OrganisationResponse": {
+            "type": "object",
+            "properties": {
+                "allowSignup": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "defaultManagerId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "requireMFA": {
+                    "type": "boolean"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "organisation.UpdateOrganisationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "allowSignup": {
+                    "description": "left unchanged when omitted",
+                    "type": "boolean"
+                },
+                "defaultManagerId": {
+                    "description": "new users stay unassigned when null",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "requireMFA": {
+                    "description": "left unchanged when omitted",
+                    "type": "boolean"
+                }
+            }
+        },
+        "reportingline.ChangeRequestResponse": {
+            "type": "object",
+            "properties": {
+                "approverId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "managerId": {
+                    "type": "string"
+                },
+                "previousManagerId": {
+                    "type": "string"
+                },
+                "requestedBy": {
+                    "type": "string"
+                },
+                "resolvedAt": {
+                    "type": "string"
+                },
+                "resolvedBy": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.AuthenticationScheme": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.BulkSupport": {
+            "type": "object",
+            "properties": {
+                "maxOperations": {
+                    "type": "integer"
+                },
+                "maxPayloadSize": {
+                    "type": "integer"
+                },
+                "supported": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "scim.Email": {
+            "type": "object",
+            "properties": {
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.EnterpriseUser": {
+            "type": "object",
+            "properties": {
+                "manager": {
+                    "$ref": "#/definitions/scim.Manager"
+                }
+            }
+        },
+        "scim.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scimType": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.FilterSupport": {
+            "type": "object",
+            "properties": {
+                "maxResults": {
+                    "type": "integer"
+                },
+                "supported": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "scim.ListResponse": {
+            "type": "object",
+            "properties": {
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scim.UserResource"
+                    }
+                },
+                "itemsPerPage": {
+                    "type": "integer"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "scim.Manager": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.Meta": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "resourceType": {
+                    "type": "string"
+                }
+            }
+        },
+        "scim.Name": {
+            "type": "object",
+            "properties": {
+                "familyName": {
+                    "type": "string"
+                },
+                "formatted": {
+                    "type": "string"
+                },
+                "givenName": {
                     "type": "string"
                 }
             }
         },
-        "one_to_one.Challenges": {
+        "scim.PatchRequest": {
+            "type": "object"
+        },
+        "scim.ServiceProviderConfig": {
+            "type": "object",
+            "properties": {
+                "authenticationSchemes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scim.AuthenticationScheme"
+                    }
+                },
+                "bulk": {
+                    "$ref": "#/definitions/scim.BulkSupport"
+                },
+                "changePassword": {
+                    "$ref": "#/definitions/scim.Supported"
+                },
+                "etag": {
+                    "$ref": "#/definitions/scim.Supported"
+                },
+                "filter": {
+                    "$ref": "#/definitions/scim.FilterSupport"
+                },
+                "patch": {
+                    "$ref": "#/definitions/scim.Supported"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sort": {
+                    "$ref": "#/definitions/scim.Supported"
+                }
+            }
+        },
+        "scim.Supported": {
+            "type": "object",
+            "properties": {
+                "supported": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "scim.UserResource": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "displayName": {
+                    "type": "string"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scim.Email"
+                    }
+                },
+                "externalId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/scim.Meta"
+                },
+                "name": {
+                    "$ref": "#/definitions/scim.Name"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {
+                    "$ref": "#/definitions/scim.EnterpriseUser"
+                },
+                "userName": {
+                    "type": "string"
+                }
+            }
+        },
+        "setup.SetupRequest": {
             "type": "object",
             "required": [
-                "label",
-                "theme"
+                "email",
+                "firstName",
+                "lastName",
+                "organisationName",
+                "organisationSlug",
+                "password"
             ],
             "properties": {
-                "label": {
+                "email": {
                     "type": "string"
                 },
-                "theme": {
+                "firstName": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "organisationName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "organisationSlug": {
+                    "description": "lowercase letters, digits and dashes, sent in the Tenant header",
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "password": {
+                    "type": "string"
+                },
+                "setupToken": {
+                    "description": "the value of SETUP_TOKEN, when set",
                     "type": "string"
                 }
             }
         },
-        "one_to_one.CreateWeeklyReportRequest": {
+        "setup.SetupStatusResponse": {
+            "type": "object",
+            "properties": {
+                "setupRequired": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user.AddReporteeRequest": {
             "type": "object",
             "required": [
-                "agendas",
-                "challenges",
-                "goneWell",
-                "week",
-                "wellbeingScores",
-                "year"
+                "reporteeEmail"
             ],
             "properties": {
-                "agendas": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/one_to_one.Agenda"
+                "reporteeEmail": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.AddReportsToRequest": {
+            "type": "object",
+            "required": [
+                "reportsToEmail"
+            ],
+            "properties": {
+                "reportsToEmail": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.Avatar": {
+            "type": "object",
+            "properties": {
+                "urls": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "user.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "currentPassword",
+                "newPassword"
+            ],
+            "properties": {
+                "currentPassword": {
+                    "type": "string"
                 },
-                "challenges": {
+                "newPassword": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.CreatePersonalAccessTokenRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "description": "never expires when omitted",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
                     "type": "array",
+                    "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/one_to_one.Challenges"
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "user.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "firstName",
+                "lastName",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
                 },
-                "goneWell": {
+                "firstName": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.CreatedPersonalAccessTokenResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked": {
+                    "type": "boolean"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/one_to_one.GoneWell"
+                        "type": "string"
                     }
                 },
-                "week": {
+                "token": {
+                    "description": "only returned once, when the token is created",
+                    "type": "string"
+                }
+            }
+        },
+        "user.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.ImpersonateRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "user.ImpersonationResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/user.UserResponse"
+                }
+            }
+        },
+        "user.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "type": "integer"
+                },
+                "mfaEnrollmentRequired": {
+                    "description": "two-factor authentication is enforced but not set up yet",
+                    "type": "boolean"
+                },
+                "refreshToken": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/user.UserResponse"
+                }
+            }
+        },
+        "user.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.MFAChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
                     "type": "integer"
                 },
-                "wellbeingScores": {
-                    "$ref": "#/definitions/one_to_one.WellbeingScores"
+                "mfaRequired": {
+                    "type": "boolean"
                 },
-                "year": {
-                    "type": "integer"
+                "mfaToken": {
+                    "type": "string"
                 }
             }
         },
-        "one_to_one.GoneWell": {
+        "user.MFACodeRequest": {
             "type": "object",
             "required": [
-                "label",
-                "theme"
+                "code"
             ],
             "properties": {
-                "label": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.MFAEnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "provisioningUri": {
                     "type": "string"
                 },
-                "theme": {
+                "secret": {
                     "type": "string"
                 }
             }
         },
-        "one_to_one.UpdateWeeklyReportRequest": {
+        "user.MFALoginRequest": {
             "type": "object",
             "required": [
-                "agendas",
-                "challenges",
-                "goneWell",
-                "id",
-                "week",
-                "wellbeingScores",
-                "year"
+                "code",
+                "mfaToken"
             ],
             "properties": {
-                "agendas": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/one_to_one.Agenda"
-                    }
-                },
-                "challenges": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/one_to_one.Challenges"
-                    }
+                "code": {
+                    "type": "string"
                 },
-                "goneWell": {
+                "mfaToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.MFARecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/one_to_one.GoneWell"
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "user.ManagerHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "manager": {
+                    "$ref": "#/definitions/user.UserSummary"
                 },
-                "id": {
+                "validFrom": {
                     "type": "string"
                 },
-                "week": {
-                    "type": "integer"
-                },
-                "wellbeingScores": {
-                    "$ref": "#/definitions/one_to_one.WellbeingScores"
-                },
-                "year": {
-                    "type": "integer"
+                "validTo": {
+                    "type": "string"
                 }
             }
         },
-        "one_to_one.WeeklyReportResponse": {
+        "user.OrgChartNode": {
             "type": "object",
             "properties": {
-                "agendas": {
+                "reportees": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/one_to_one.Agenda"
+                        "$ref": "#/definitions/user.OrgChartNode"
                     }
                 },
-                "challenges": {
+                "user": {
+                    "$ref": "#/definitions/user.UserSummary"
+                }
+            }
+        },
+        "user.OrgChartResponse": {
+            "type": "object",
+            "properties": {
+                "ancestors": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/one_to_one.Challenges"
+                        "$ref": "#/definitions/user.UserSummary"
                     }
                 },
+                "tree": {
+                    "$ref": "#/definitions/user.OrgChartNode"
+                }
+            }
+        },
+        "user.PersonalAccessTokenResponse": {
+            "type": "object",
+            "properties": {
                 "createdAt": {
                     "type": "string"
                 },
-                "goneWell": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/one_to_one.GoneWell"
-                    }
+                "expiresAt": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "reportee": {
+                "lastUsedAt": {
                     "type": "string"
                 },
-                "reportingTo": {
+                "name": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "prefix": {
                     "type": "string"
                 },
-                "week": {
-                    "type": "integer"
-                },
-                "wellbeingScores": {
-                    "$ref": "#/definitions/one_to_one.WellbeingScores"
+                "revoked": {
+                    "type": "boolean"
                 },
-                "year": {
-                    "type": "integer"
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "one_to_one.WellbeingScores": {
+        "user.RefreshTokenRequest": {
             "type": "object",
             "required": [
-                "growth",
-                "impactAndProductivity",
-                "wellbeing",
-                "workOverall",
-                "workRelationships"
+                "refreshToken"
             ],
             "properties": {
-                "growth": {
-                    "type": "integer"
-                },
-                "impactAndProductivity": {
-                    "type": "integer"
-                },
-                "wellbeing": {
-                    "type": "integer"
-                },
-                "workOverall": {
-                    "type": "integer"
-                },
-                "workRelationships": {
-                    "type": "integer"
+                "refreshToken": {
+                    "type": "string"
                 }
             }
         },
-        "user.AddReporteeRequest": {
+        "user.RemoveReporteeRequest": {
             "type": "object",
             "required": [
                 "reporteeEmail"
@@ -947,96 +5158,184 @@ const docTemplate = `{
                 }
             }
         },
-        "user.AddReportsToRequest": {
+        "user.ResendVerificationRequest": {
             "type": "object",
             "required": [
-                "reportsToEmail"
+                "email"
             ],
             "properties": {
-                "reportsToEmail": {
+                "email": {
                     "type": "string"
                 }
             }
         },
-        "user.CreateUserRequest": {
+        "user.ResetPasswordRequest": {
             "type": "object",
             "required": [
-                "email",
-                "firstName",
-                "lastName",
-                "password"
+                "password",
+                "token"
             ],
             "properties": {
-                "email": {
+                "password": {
                     "type": "string"
                 },
-                "firstName": {
+                "token": {
                     "type": "string"
+                }
+            }
+        },
+        "user.RoleResponse": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "lastName": {
+                "role": {
                     "type": "string"
-                },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
                 }
             }
         },
-        "user.LoginRequest": {
+        "user.SessionResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "createdAt": {
                     "type": "string"
                 },
-                "password": {
+                "current": {
+                    "description": "the session of the token used for the request",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "userAgent": {
                     "type": "string"
                 }
             }
         },
-        "user.LoginResponse": {
+        "user.TokenResponse": {
             "type": "object",
             "properties": {
+                "expiresIn": {
+                    "type": "integer"
+                },
+                "refreshToken": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
+                }
+            }
+        },
+        "user.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "department": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "firstName": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "jobTitle": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "locale": {
+                    "description": "BCP 47 language tag, e.g. en-GB",
+                    "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/user.UserResponse"
+                "location": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "pronouns": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "timezone": {
+                    "description": "IANA time zone, e.g. Europe/London",
+                    "type": "string"
                 }
             }
         },
-        "user.RemoveReporteeRequest": {
+        "user.UpdateRolesRequest": {
             "type": "object",
             "required": [
-                "reporteeEmail"
+                "roles"
             ],
             "properties": {
-                "reporteeEmail": {
-                    "type": "string"
+                "roles": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
         "user.UserResponse": {
             "type": "object",
             "properties": {
+                "avatar": {
+                    "description": "URL of the avatar by size, see AvatarSizes",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "department": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
+                "emailVerified": {
+                    "type": "boolean"
+                },
                 "firstName": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
+                "jobTitle": {
+                    "type": "string"
+                },
                 "lastName": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "mfaEnabled": {
+                    "type": "boolean"
+                },
+                "pronouns": {
+                    "type": "string"
+                },
                 "reportees": {
                     "type": "array",
                     "items": {
@@ -1046,10 +5345,55 @@ const docTemplate = `{
                 "reportsTo": {
                     "type": "string"
                 },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 }
             }
+        },
+        "user.UserSummary": {
+            "type": "object",
+            "required": [
+                "email",
+                "firstName",
+                "lastName"
+            ],
+            "properties": {
+                "avatar": {
+                    "$ref": "#/definitions/user.Avatar"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -7,10 +7,11 @@ import (
 // --------------- Response structures ---------------
 
 type ResponseData struct {
-	Status  int           `json:"status"`
-	Message string        `json:"message"`
-	Data    interface{}   `json:"data,omitempty"`
-	Errors  *[]FieldError `json:"errors,omitempty"`
+	Status        int            `json:"status"`
+	Message       string         `json:"message"`
+	Data          interface{}    `json:"data,omitempty"`
+	Errors        *[]FieldError  `json:"errors,omitempty"`
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
 }
 
 type FieldError struct {
@@ -18,6 +19,15 @@ type FieldError struct {
 	Message string  `json:"message"`
 }
 
+// Impersonation flags responses to requests made by an admin acting as another user. The
+// auth middleware stores it in the context under ImpersonationKey.
+type Impersonation struct {
+	ImpersonatorID    string `json:"impersonatorId"`
+	ImpersonatorEmail string `json:"impersonatorEmail"`
+}
+
+const ImpersonationKey = "impersonation"
+
 // --------------- Response functions ---------------
 
 func ApiResponse(c *gin.Context, statusCode int, message string, data interface{}, errors *[]FieldError) {
@@ -28,6 +38,12 @@ func ApiResponse(c *gin.Context, statusCode int, message string, data interface{
 		Errors:  errors,
 	}
 
+	if impersonation, ok := c.Get(ImpersonationKey); ok {
+		if value, ok := impersonation.(Impersonation); ok {
+			response.Impersonation = &value
+		}
+	}
+
 	c.JSON(statusCode, response)
 }
 
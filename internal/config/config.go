@@ -12,6 +12,15 @@ type Config struct {
 		ApiVersion  string `envconfig:"API_VERSION" default:"v0"`
 		AppVersion  string `envconfig:"APP_VERSION" default:"v0.0.1"`
 		Environment string `envconfig:"ENVIRONMENT" default:"local"`
+		ClientURL   string `envconfig:"CLIENT_URL" default:"http://localhost:3000"`
+		// Client IP addresses are only read from X-Forwarded-For when the request comes from one
+		// of the trusted proxies. Behind a platform that sets its own header, such as X-Real-IP,
+		// set ClientIPHeader instead.
+		TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+		ClientIPHeader string   `envconfig:"CLIENT_IP_HEADER"`
+		// SetupToken has to be presented to the first-run setup, which creates the organisation
+		// and its first admin. Outside local, the setup is disabled until it is set.
+		SetupToken string `envconfig:"SETUP_TOKEN"`
 	}
 	Database struct {
 		MongoURI      string `envconfig:"DATABASE_URL" default:"mongodb://localhost:27017"`
@@ -19,11 +28,56 @@ type Config struct {
 		MongoDBName   string `envconfig:"MONGODB_DB_NAME" default:"one-to-one"`
 	}
 	Auth struct {
-		JWTSecret        string `envconfig:"JWT_SECRET" default:"token-secret"`
-		JWTExpireInHours int    `envconfig:"JWT_EXPIRE" default:"24"`
-		TokenExpire      int    `envconfig:"TOKEN_EXPIRE" default:"60"`
-		ShortTokenExpire int    `envconfig:"SHORT_TOKEN_EXPIRE" default:"15"`
-		JWTIssuer        string `envconfig:"JWT_ISSUER" default:"one-to-one.vercel.app"`
+		JWTSecret               string   `envconfig:"JWT_SECRET" default:"token-secret"` // HS256 secret, used when no signing key is set
+		JWTSigningKey           string   `envconfig:"JWT_SIGNING_KEY"`                   // PEM encoded RSA or Ed25519 private key
+		JWTVerificationKeys     []string `envconfig:"JWT_VERIFICATION_KEYS"`             // PEM encoded public keys of retired signing keys
+		JWTAcceptHS256          bool     `envconfig:"JWT_ACCEPT_HS256" default:"false"`  // keep accepting JWT_SECRET tokens next to JWT_SIGNING_KEY while switching over
+		TokenExpire             int      `envconfig:"TOKEN_EXPIRE" default:"60"`         // refresh token lifetime in days
+		ShortTokenExpire        int      `envconfig:"SHORT_TOKEN_EXPIRE" default:"15"`   // access token lifetime in minutes
+		JWTIssuer               string   `envconfig:"JWT_ISSUER" default:"one-to-one.vercel.app"`
+		ResetTokenExpire        int      `envconfig:"RESET_TOKEN_EXPIRE" default:"30"`        // password reset token lifetime in minutes
+		EmailVerification       string   `envconfig:"EMAIL_VERIFICATION" default:"none"`      // "none", "login" or "protected"
+		VerificationTokenExpire int      `envconfig:"VERIFICATION_TOKEN_EXPIRE" default:"48"` // verification link lifetime in hours
+		RequireMFA              bool     `envconfig:"REQUIRE_MFA" default:"false"`            // enforce two-factor authentication in every organisation
+		MFAIssuer               string   `envconfig:"MFA_ISSUER" default:"OneToOne"`
+		MFAChallengeExpire      int      `envconfig:"MFA_CHALLENGE_EXPIRE" default:"5"`       // MFA challenge token lifetime in minutes
+		MaxLoginAttempts        int      `envconfig:"MAX_LOGIN_ATTEMPTS" default:"5"`         // failed logins per account before it is locked
+		MaxLoginAttemptsPerIP   int      `envconfig:"MAX_LOGIN_ATTEMPTS_PER_IP" default:"50"` // failed logins per IP address before it is locked
+		LoginLockout            int      `envconfig:"LOGIN_LOCKOUT" default:"1"`              // first lockout in minutes, doubled on every further failure
+		MaxLoginLockout         int      `envconfig:"MAX_LOGIN_LOCKOUT" default:"60"`         // longest lockout in minutes
+		LoginAttemptWindow      int      `envconfig:"LOGIN_ATTEMPT_WINDOW" default:"60"`      // minutes without failures after which the count is reset
+		PasswordMinLength       int      `envconfig:"PASSWORD_MIN_LENGTH" default:"8"`
+		PasswordHistory         int      `envconfig:"PASSWORD_HISTORY" default:"5"`      // recent passwords, including the current one, that cannot be reused
+		BreachedPasswordsFile   string   `envconfig:"BREACHED_PASSWORDS_FILE"`           // one password or SHA-1 hash per line
+		ImpersonationExpire     int      `envconfig:"IMPERSONATION_EXPIRE" default:"15"` // impersonation token lifetime in minutes
+		InvitationExpire        int      `envconfig:"INVITATION_EXPIRE" default:"7"`     // invitation link lifetime in days
+	}
+	OIDC struct {
+		IssuerURL    string   `envconfig:"OIDC_ISSUER_URL"`
+		ClientID     string   `envconfig:"OIDC_CLIENT_ID"`
+		ClientSecret string   `envconfig:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string   `envconfig:"OIDC_REDIRECT_URL"`
+		Scopes       []string `envconfig:"OIDC_SCOPES" default:"openid,email,profile"`
+	}
+	LDAP struct {
+		URL              string `envconfig:"LDAP_URL"` // ldap:// or ldaps:// URL of the directory, the sync is disabled when empty
+		StartTLS         bool   `envconfig:"LDAP_START_TLS" default:"false"`
+		BindDN           string `envconfig:"LDAP_BIND_DN"`
+		BindPassword     string `envconfig:"LDAP_BIND_PASSWORD"`
+		BaseDN           string `envconfig:"LDAP_BASE_DN"`
+		UserFilter       string `envconfig:"LDAP_USER_FILTER" default:"(&(objectClass=person)(mail=*))"`
+		SyncDryRun       bool   `envconfig:"LDAP_SYNC_DRY_RUN" default:"false"`   // scheduled syncs only report the changes they would make
+		MaxDeactivations int    `envconfig:"LDAP_MAX_DEACTIVATIONS" default:"20"` // syncs that would deactivate more users are not applied, 0 for no limit
+	}
+	SCIM struct {
+		Token string `envconfig:"SCIM_TOKEN"` // bearer token of the SCIM client, SCIM is disabled when empty
+	}
+	Mail struct {
+		Host     string `envconfig:"SMTP_HOST"`
+		Port     string `envconfig:"SMTP_PORT" default:"587"`
+		Username string `envconfig:"SMTP_USERNAME"`
+		Password string `envconfig:"SMTP_PASSWORD"`
+		From     string `envconfig:"MAIL_FROM" default:"no-reply@one-to-one.vercel.app"`
 	}
 	Pusher struct {
 		AppID   string `envconfig:"PUSHER_APP_ID"`
@@ -31,9 +85,21 @@ type Config struct {
 		Secret  string `envconfig:"PUSHER_SECRET"`
 		Cluster string `envconfig:"PUSHER_CLUSTER"`
 	}
+	Storage struct {
+		Backend       string `envconfig:"STORAGE_BACKEND" default:"local"` // "local" or "s3"
+		LocalDir      string `envconfig:"STORAGE_LOCAL_DIR" default:"uploads"`
+		PublicURL     string `envconfig:"STORAGE_PUBLIC_URL"` // base URL of the stored files, served by the API under /uploads with the local backend by default
+		S3Endpoint    string `envconfig:"STORAGE_S3_ENDPOINT" default:"https://s3.amazonaws.com"`
+		S3Region      string `envconfig:"STORAGE_S3_REGION" default:"us-east-1"`
+		S3Bucket      string `envconfig:"STORAGE_S3_BUCKET"`
+		S3AccessKey   string `envconfig:"STORAGE_S3_ACCESS_KEY"`
+		S3SecretKey   string `envconfig:"STORAGE_S3_SECRET_KEY"`
+		S3PathStyle   bool   `envconfig:"STORAGE_S3_PATH_STYLE" default:"true"` // bucket in the path rather than the host name, as MinIO expects
+		AvatarMaxSize int64  `envconfig:"AVATAR_MAX_SIZE" default:"5242880"`    // largest avatar upload in bytes
+	}
 	Cors struct {
 		AllowOrigins     []string `envconfig:"CORS_ALLOW_ORIGINS" default:"*"`
-		AllowMethods     []string `envconfig:"CORS_ALLOW_METHODS" default:"GET, POST, PUT, DELETE, OPTIONS"`
+		AllowMethods     []string `envconfig:"CORS_ALLOW_METHODS" default:"GET, POST, PUT, PATCH, DELETE, OPTIONS"`
 		AllowHeaders     []string `envconfig:"CORS_ALLOW_HEADERS" default:"Origin, Content-Length, Content-Type, Authorization, Tenant"`
 		AllowCredentials bool     `envconfig:"CORS_ALLOW_CREDENTIALS" default:"true"`
 	}
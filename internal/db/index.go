@@ -13,6 +13,18 @@ import (
 const DATABASE_NAME = "one-to-one"
 const COLLECTION_USER = "User"
 const COLLECTION_WEEKLY_REPORT = "WeeklyReport"
+const COLLECTION_REFRESH_TOKEN = "RefreshToken"
+const COLLECTION_REVOKED_TOKEN = "RevokedToken"
+const COLLECTION_PASSWORD_RESET_TOKEN = "PasswordResetToken"
+const COLLECTION_OIDC_STATE = "OIDCState"
+const COLLECTION_PERSONAL_ACCESS_TOKEN = "PersonalAccessToken"
+const COLLECTION_LOGIN_ATTEMPT = "LoginAttempt"
+const COLLECTION_SESSION = "Session"
+const COLLECTION_AUDIT_LOG = "AuditLog"
+const COLLECTION_INVITATION = "Invitation"
+const COLLECTION_ORGANISATION = "Organisation"
+const COLLECTION_REPORTING_LINE = "ReportingLine"
+const COLLECTION_REPORTING_LINE_REQUEST = "ReportingLineRequest"
 
 var Client *mongo.Client
 var isConnected bool = false
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"one-to-one/internal/config"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional emails such as password reset links.
+type Mailer interface {
+	Send(c context.Context, msg Message) error
+}
+
+var Client Mailer
+
+// Init picks the mailer implementation from the configuration. Without an SMTP host the
+// emails are kept in memory, which is what local development and tests want.
+func Init() {
+	if config.AppConfig().Mail.Host == "" {
+		log.Println("No SMTP host configured, emails will be kept in memory.")
+		Client = NewMemoryMailer()
+		return
+	}
+
+	Client = NewSMTPMailer(
+		config.AppConfig().Mail.Host,
+		config.AppConfig().Mail.Port,
+		config.AppConfig().Mail.Username,
+		config.AppConfig().Mail.Password,
+		config.AppConfig().Mail.From,
+	)
+}
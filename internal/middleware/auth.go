@@ -1,38 +1,99 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"one-to-one/internal/api"
 	"one-to-one/internal/config"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/pkg/utils"
 )
 
-var jwtSecret = []byte(config.AppConfig().Auth.JWTSecret)
+// Email verification policies, see config.Auth.EmailVerification.
+const (
+	EmailVerificationNone      = "none"
+	EmailVerificationLogin     = "login"
+	EmailVerificationProtected = "protected"
+)
+
+// Ways a request can be authenticated, stored in the context under "authMethod".
+const (
+	AuthMethodJWT                 = "jwt"
+	AuthMethodPersonalAccessToken = "pat"
+)
+
+// TokenSubject describes the user an access token is issued to.
+type TokenSubject struct {
+	Email          string
+	UserID         string
+	OrganisationID string
+	EmailVerified  bool
+	Roles          []string
+	MFA            bool
+	SessionID      string
+}
+
+// GenerateJWTToken issues a short-lived access token for the user. Longer sessions are kept alive
+// through refresh tokens rather than by extending the lifetime of this token.
+func GenerateJWTToken(subject TokenSubject) (string, error) {
+	ttl := time.Minute * time.Duration(config.AppConfig().Auth.ShortTokenExpire)
+	return signToken(accessTokenClaims(subject, ttl))
+}
+
+// GenerateImpersonationToken issues an access token for subject on behalf of an admin. The
+// admin is kept in the act claim of RFC 8693. The token cannot be refreshed.
+func GenerateImpersonationToken(subject TokenSubject, impersonator api.Impersonation, ttl time.Duration) (string, error) {
+	claims := accessTokenClaims(subject, ttl)
+	claims["act"] = map[string]interface{}{
+		"sub":   impersonator.ImpersonatorID,
+		"email": impersonator.ImpersonatorEmail,
+	}
 
-func GenerateJWTToken(email string, userId string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+	return signToken(claims)
+}
 
+func accessTokenClaims(subject TokenSubject, ttl time.Duration) jwt.MapClaims {
 	// Create a map to store our claims
-	claims := token.Claims.(jwt.MapClaims)
+	claims := jwt.MapClaims{}
 
 	// Set token claims
 	claims["iss"] = config.AppConfig().Auth.JWTIssuer
-	claims["email"] = email
-	claims["userId"] = userId
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(config.AppConfig().Auth.JWTExpireInHours)).Unix()
+	claims["jti"] = utils.GenerateID()
+	claims["email"] = subject.Email
+	claims["userId"] = subject.UserID
+	claims["org"] = subject.OrganisationID
+	claims["emailVerified"] = subject.EmailVerified
+	claims["roles"] = subject.Roles
+	claims["mfa"] = subject.MFA
+	claims["sid"] = subject.SessionID
+	claims["exp"] = time.Now().Add(ttl).Unix()
 	claims["iat"] = time.Now().Unix()
 
-	tokenString, err := token.SignedString(jwtSecret)
-	return tokenString, err
+	return claims
 }
 
 // JWTAuthMiddleware is a middleware to authenticate the user using JWT
 func JWTAuthMiddleware() gin.HandlerFunc {
+	return authMiddleware(false)
+}
+
+// TokenAuthMiddleware authenticates the user with either a JWT or a personal access token.
+// Routes behind it should declare what they need with RequireScope.
+func TokenAuthMiddleware() gin.HandlerFunc {
+	return authMiddleware(true)
+}
+
+func authMiddleware(allowPersonalAccessTokens bool) gin.HandlerFunc {
+	authRepo := auth.NewAuthRepository()
+	auditRepo := audit.NewAuditRepository()
+
 	return func(c *gin.Context) {
 		const BearerSchema = "Bearer "
 		authHeader := c.GetHeader("Authorization")
@@ -43,41 +104,167 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := authHeader[len(BearerSchema):]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		if auth.IsPersonalAccessToken(tokenString) {
+			if !allowPersonalAccessTokens {
+				api.Error(c, http.StatusUnauthorized, "Personal access tokens are not accepted for this route", nil)
+				return
 			}
-			return []byte(jwtSecret), nil
-		})
+			authenticatePersonalAccessToken(c, authRepo, tokenString)
+		} else {
+			authenticateJWT(c, authRepo, tokenString)
+		}
 
-		if err != nil {
-			var errMsg string
-			if ve, ok := err.(*jwt.ValidationError); ok {
-				if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-					errMsg = "Malformed token"
-				} else if ve.Errors&jwt.ValidationErrorExpired != 0 {
-					errMsg = "Token is expired"
-				} else if ve.Errors&jwt.ValidationErrorNotValidYet != 0 {
-					errMsg = "Token not valid yet"
-				} else {
-					errMsg = "Invalid token"
-				}
+		if c.IsAborted() {
+			return
+		}
+
+		c.Next()
+
+		if IsImpersonating(c) {
+			auditImpersonatedRequest(c, auditRepo)
+		}
+	}
+}
+
+// authenticateJWT validates an access token and stores its claims in the context. It aborts
+// the request when the token is rejected.
+func authenticateJWT(c *gin.Context, authRepo auth.AuthRepository, tokenString string) {
+	token, err := jwt.Parse(tokenString, verificationKey)
+
+	if err != nil {
+		var errMsg string
+		if ve, ok := err.(*jwt.ValidationError); ok {
+			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
+				errMsg = "Malformed token"
+			} else if ve.Errors&jwt.ValidationErrorExpired != 0 {
+				errMsg = "Token is expired"
+			} else if ve.Errors&jwt.ValidationErrorNotValidYet != 0 {
+				errMsg = "Token not valid yet"
 			} else {
 				errMsg = "Invalid token"
 			}
-			api.Error(c, http.StatusUnauthorized, errMsg, nil)
-			return
+		} else {
+			errMsg = "Invalid token"
 		}
+		api.Error(c, http.StatusUnauthorized, errMsg, nil)
+		return
+	}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("email", claims["email"])
-			c.Set("userId", claims["userId"])
-			c.Set("accountType", claims["accountType"])
-		} else {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	emailVerified, _ := claims["emailVerified"].(bool)
+	if config.AppConfig().Auth.EmailVerification == EmailVerificationProtected && !emailVerified {
+		api.Error(c, http.StatusForbidden, "Email address has not been verified", nil)
+		return
+	}
+
+	userId, _ := claims["userId"].(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	// Tokens issued before organisations were introduced, or to users that belong to none yet,
+	// stay scoped to the organisation of the request.
+	if organisationId, _ := claims["org"].(string); organisationId != "" {
+		organisationObjectID, err := primitive.ObjectIDFromHex(organisationId)
+		if err != nil {
 			api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
 			return
 		}
+		if !organisationObjectID.IsZero() && !scopeToTokenOrganisation(c, organisationObjectID) {
+			return
+		}
+	}
+
+	// Tokens issued before sessions were recorded have no sid and are left to expire.
+	sessionId, _ := claims["sid"].(string)
+	sessionObjectID, _ := primitive.ObjectIDFromHex(sessionId)
+
+	jti, _ := claims["jti"].(string)
+	issuedAt := time.Unix(int64(numericClaim(claims, "iat")), 0)
+	revoked, err := authRepo.IsTokenRevoked(c.Request.Context(), jti, userObjectID, sessionObjectID, issuedAt)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+	if revoked {
+		api.Error(c, http.StatusUnauthorized, "Token has been revoked", nil)
+		return
+	}
+
+	if !sessionObjectID.IsZero() {
+		if err := authRepo.TouchSession(c.Request.Context(), sessionObjectID, c.ClientIP()); err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+	}
+
+	c.Set("authMethod", AuthMethodJWT)
+	c.Set("email", claims["email"])
+	c.Set("userId", claims["userId"])
+	c.Set("roles", stringSliceClaim(claims, "roles"))
+	c.Set("mfa", claims["mfa"] == true)
+	c.Set("emailVerified", emailVerified)
+	c.Set("jti", jti)
+	c.Set("sessionId", sessionId)
+
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		impersonatorId, _ := act["sub"].(string)
+		impersonatorEmail, _ := act["email"].(string)
+		c.Set(api.ImpersonationKey, api.Impersonation{ImpersonatorID: impersonatorId, ImpersonatorEmail: impersonatorEmail})
+		c.Header("X-Impersonated-By", impersonatorEmail)
+	}
+	c.Set("tokenExpiresAt", time.Unix(int64(numericClaim(claims, "exp")), 0))
+}
+
+// RequireMFA rejects tokens that did not pass the second factor while the organisation of the
+// request enforces two-factor authentication. It must run after JWTAuthMiddleware, on every
+// protected route except the few needed to enroll.
+func RequireMFA() gin.HandlerFunc {
+	orgRepo := organisation.NewOrganisationRepository()
+
+	return func(c *gin.Context) {
+		if c.GetBool("mfa") {
+			c.Next()
+			return
+		}
+
+		required, err := orgRepo.MFARequired(c.Request.Context())
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+		if required {
+			api.Error(c, http.StatusForbidden, "Two-factor authentication is required", nil)
+			return
+		}
 
 		c.Next()
 	}
 }
+
+// numericClaim returns a numeric claim, or zero when it is missing. JSON numbers are decoded as
+// float64 by the jwt package.
+func numericClaim(claims jwt.MapClaims, key string) float64 {
+	value, _ := claims[key].(float64)
+	return value
+}
+
+// stringSliceClaim returns a claim holding a list of strings, or nil when it is missing.
+func stringSliceClaim(claims jwt.MapClaims, key string) []string {
+	values, _ := claims[key].([]interface{})
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
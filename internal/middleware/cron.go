@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+)
+
+// CronAuthMiddleware lets scheduled jobs through. Vercel sends CRON_SECRET as a bearer token
+// with every cron invocation. Without a configured secret, cron routes cannot be called.
+func CronAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := config.AppConfig().Vercel.CronSecret
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		expected, presented := sha256.Sum256([]byte(secret)), sha256.Sum256([]byte(token))
+		if secret == "" || !found || subtle.ConstantTimeCompare(expected[:], presented[:]) != 1 {
+			api.Error(c, http.StatusUnauthorized, "Invalid cron secret", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
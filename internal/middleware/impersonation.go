@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/api"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/tenant"
+)
+
+// IsImpersonating reports whether the request was made with an impersonation token.
+func IsImpersonating(c *gin.Context) bool {
+	_, ok := c.Get(api.ImpersonationKey)
+	return ok
+}
+
+// ForbidImpersonation rejects impersonation tokens on routes that manage the account itself,
+// such as its password, second factor or sessions. It must run after JWTAuthMiddleware.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsImpersonating(c) {
+			api.Error(c, http.StatusForbidden, "This action is not allowed while impersonating a user", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// auditImpersonatedRequest writes a request made with an impersonation token to the audit log.
+// It runs after the handler so the outcome can be recorded, and does not depend on the request
+// context, which is gone once the client disconnects.
+func auditImpersonatedRequest(c *gin.Context, auditRepo audit.AuditRepository) {
+	value, _ := c.Get(api.ImpersonationKey)
+	impersonation := value.(api.Impersonation)
+
+	actorID, _ := primitive.ObjectIDFromHex(impersonation.ImpersonatorID)
+	entry := audit.Entry{
+		Action:       audit.ActionImpersonationRequest,
+		ActorID:      actorID,
+		ActorEmail:   impersonation.ImpersonatorEmail,
+		SubjectEmail: c.GetString("email"),
+		IP:           c.ClientIP(),
+		Details: map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"query":  c.Request.URL.RawQuery,
+			"status": c.Writer.Status(),
+			"jti":    c.GetString("jti"),
+		},
+	}
+	if subjectID, err := primitive.ObjectIDFromHex(c.GetString("userId")); err == nil {
+		entry.SubjectID = &subjectID
+	}
+	// The entry outlives the request context, so it carries the organisation itself.
+	entry.OrganisationID, _ = tenant.OrganisationID(c.Request.Context())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := auditRepo.Record(ctx, entry); err != nil {
+		log.Println("Failed to write audit log entry: ", err)
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+)
+
+// authenticatePersonalAccessToken validates a personal access token and stores its owner and
+// scopes in the context. It aborts the request when the token is rejected.
+func authenticatePersonalAccessToken(c *gin.Context, authRepo auth.AuthRepository, tokenString string) {
+	pat, err := authRepo.GetPersonalAccessTokenByHash(c.Request.Context(), auth.HashToken(tokenString))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if pat.RevokedAt != nil {
+		api.Error(c, http.StatusUnauthorized, "Token has been revoked", nil)
+		return
+	}
+	if pat.ExpiresAt != nil && pat.ExpiresAt.Time().Before(time.Now()) {
+		api.Error(c, http.StatusUnauthorized, "Token is expired", nil)
+		return
+	}
+
+	principal, err := authRepo.GetPrincipal(c.Request.Context(), pat.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if principal.DeactivatedAt != nil {
+		api.Error(c, http.StatusUnauthorized, "Account has been deactivated", nil)
+		return
+	}
+
+	if config.AppConfig().Auth.EmailVerification == EmailVerificationProtected && !principal.EmailVerified {
+		api.Error(c, http.StatusForbidden, "Email address has not been verified", nil)
+		return
+	}
+
+	if !principal.OrganisationID.IsZero() && !scopeToTokenOrganisation(c, principal.OrganisationID) {
+		return
+	}
+
+	if err := authRepo.TouchPersonalAccessToken(c.Request.Context(), pat.ID); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	c.Set("authMethod", AuthMethodPersonalAccessToken)
+	c.Set("email", principal.Email)
+	c.Set("userId", principal.ID.Hex())
+	c.Set("roles", principal.Roles)
+	c.Set("scopes", pat.Scopes)
+	c.Set("mfa", pat.MFA)
+	c.Set("emailVerified", principal.EmailVerified)
+}
+
+// RequireScope rejects personal access tokens that were not granted the scope. Interactive
+// JWT sessions have every scope. It must run after TokenAuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authMethod") == AuthMethodPersonalAccessToken && !auth.HasScope(c.GetStringSlice("scopes"), scope) {
+			api.Error(c, http.StatusForbidden, "Token is missing the "+scope+" scope", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/api"
+	"one-to-one/internal/services/auth"
+)
+
+// RequireRole only lets the request through when the authenticated user holds one of the roles.
+// It must run after JWTAuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasRole(c.GetStringSlice("roles"), roles...) {
+			api.Error(c, http.StatusForbidden, "You do not have permission to perform this action", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission only lets the request through when one of the user's roles grants the
+// permission. It must run after JWTAuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasPermission(c.GetStringSlice("roles"), permission) {
+			api.Error(c, http.StatusForbidden, "You do not have permission to perform this action", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+	"one-to-one/internal/config"
+)
+
+// defaultJWTSecret is the default of config.Auth.JWTSecret. It is only good enough for local
+// development.
+const defaultJWTSecret = "token-secret"
+
+// signingKey is one asymmetric key of the key ring. private is nil for keys that are only kept
+// to verify tokens issued before a rotation.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// keyRing holds the key tokens are signed with and every key they are verified with.
+type keyRing struct {
+	signing      *signingKey // nil when tokens are signed with hmac
+	verification map[string]*signingKey
+	hmac         []byte // nil when HS256 tokens are not accepted
+}
+
+var (
+	keysOnce sync.Once
+	keys     *keyRing
+	keysErr  error
+)
+
+// InitSigningKeys loads the signing keys from the configuration. It is called at startup so
+// that a bad key, or the default secret outside local, stops the server before it serves a
+// request. The keys are otherwise loaded on first use.
+func InitSigningKeys() error {
+	_, err := loadedKeys()
+	return err
+}
+
+func loadedKeys() (*keyRing, error) {
+	keysOnce.Do(func() {
+		keys, keysErr = loadKeyRing()
+	})
+	return keys, keysErr
+}
+
+// loadKeyRing builds the key ring. Tokens are signed with JWT_SIGNING_KEY when it is set and
+// with JWT_SECRET otherwise. JWT_ACCEPT_HS256 keeps JWT_SECRET tokens valid next to a signing key,
+// so that the access tokens already handed out survive the move to asymmetric keys. It is meant
+// to be turned off again once they have expired.
+func loadKeyRing() (*keyRing, error) {
+	authConfig := config.AppConfig().Auth
+	ring := &keyRing{verification: map[string]*signingKey{}}
+
+	if authConfig.JWTSigningKey != "" {
+		key, err := parsePrivateKey(authConfig.JWTSigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY: %w", err)
+		}
+		ring.signing = key
+		ring.verification[key.id] = key
+	}
+
+	for i, pem := range authConfig.JWTVerificationKeys {
+		key, err := parsePublicKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_VERIFICATION_KEYS[%d]: %w", i, err)
+		}
+		ring.verification[key.id] = key
+	}
+
+	if ring.signing == nil || authConfig.JWTAcceptHS256 {
+		ring.hmac = []byte(authConfig.JWTSecret)
+	}
+
+	if ring.hmac != nil && authConfig.JWTSecret == defaultJWTSecret && config.AppConfig().App.Environment != "local" {
+		if ring.signing != nil {
+			return nil, errors.New("JWT_ACCEPT_HS256 is set but JWT_SECRET is the insecure default")
+		}
+		return nil, errors.New("JWT_SECRET is the insecure default, set JWT_SIGNING_KEY or JWT_SECRET")
+	}
+
+	return ring, nil
+}
+
+// signToken signs claims with the current signing key.
+func signToken(claims jwt.MapClaims) (string, error) {
+	ring, err := loadedKeys()
+	if err != nil {
+		return "", err
+	}
+
+	if ring.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ring.hmac)
+	}
+
+	token := jwt.NewWithClaims(ring.signing.method, claims)
+	token.Header["kid"] = ring.signing.id
+	return token.SignedString(ring.signing.private)
+}
+
+// verificationKey is the jwt.Keyfunc for tokens issued by this server. The algorithm in the
+// header has to match the type of the key it names.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	ring, err := loadedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method == jwt.SigningMethodHS256 {
+		if ring.hmac == nil {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return ring.hmac, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ring.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet returns the public keys other services can verify our tokens with. HS256
+// secrets are never published, so the set is empty until a signing key is configured.
+func JSONWebKeySet() ([]JSONWebKey, error) {
+	ring, err := loadedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	set := make([]JSONWebKey, 0, len(ring.verification))
+	for _, key := range ring.verification {
+		set = append(set, jsonWebKey(key))
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].Kid < set[j].Kid })
+
+	return set, nil
+}
+
+func jsonWebKey(key *signingKey) JSONWebKey {
+	jwk := JSONWebKey{Use: "sig", Alg: key.method.Alg(), Kid: key.id}
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// keyID derives the kid of a key from its RFC 7638 thumbprint, so a key keeps its ID when it
+// moves from JWT_SIGNING_KEY to JWT_VERIFICATION_KEYS.
+func keyID(key *signingKey) string {
+	jwk := jsonWebKey(key)
+
+	// The members have to be in lexicographic order.
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// parsePrivateKey reads an RSA or Ed25519 private key in PEM format.
+func parsePrivateKey(data string) (*signingKey, error) {
+	pem := []byte(unescapePEM(data))
+
+	key := &signingKey{}
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+		key.method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+	} else if private, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	} else {
+		return nil, errors.New("not an RSA or Ed25519 private key")
+	}
+
+	key.id = keyID(key)
+	return key, nil
+}
+
+// parsePublicKey reads an RSA or Ed25519 public key in PEM format.
+func parsePublicKey(data string) (*signingKey, error) {
+	pem := []byte(unescapePEM(data))
+
+	key := &signingKey{}
+	if public, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		key.method, key.public = jwt.SigningMethodRS256, public
+	} else if public, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+		key.method, key.public = jwt.SigningMethodEdDSA, public
+	} else {
+		return nil, errors.New("not an RSA or Ed25519 public key")
+	}
+
+	key.id = keyID(key)
+	return key, nil
+}
+
+// unescapePEM turns the literal \n that most hosting dashboards require back into new lines.
+func unescapePEM(data string) string {
+	return strings.ReplaceAll(strings.TrimSpace(data), `\n`, "\n")
+}
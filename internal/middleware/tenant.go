@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/api"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/tenant"
+)
+
+// TenantHeader names the organisation of a request, by slug or ID. Browser redirects, which
+// cannot set headers, name it in the tenant query parameter instead.
+const (
+	TenantHeader     = "Tenant"
+	TenantQueryParam = "tenant"
+)
+
+// TenantMiddleware scopes every request to an organisation, the one named by the Tenant header
+// or else the one created by the first-run setup. Before the setup has run, requests are left
+// unscoped and every tenant-scoped query matches nothing. The auth middleware then scopes
+// authenticated requests to the organisation of their token.
+func TenantMiddleware() gin.HandlerFunc {
+	orgRepo := organisation.NewOrganisationRepository()
+
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader(TenantHeader))
+		if header == "" {
+			header = strings.TrimSpace(c.Query(TenantQueryParam))
+		}
+
+		var org *organisation.Organisation
+		var err error
+		if header != "" {
+			org, err = orgRepo.GetOrganisationBySlug(c.Request.Context(), header)
+			if id, idErr := primitive.ObjectIDFromHex(header); err == mongo.ErrNoDocuments && idErr == nil {
+				org, err = orgRepo.GetOrganisationByID(c.Request.Context(), id)
+			}
+		} else {
+			org, err = orgRepo.GetBootstrapOrganisation(c.Request.Context())
+		}
+
+		if err == mongo.ErrNoDocuments {
+			if header != "" {
+				api.Error(c, http.StatusNotFound, "Organisation not found", nil)
+				return
+			}
+			c.Next()
+			return
+		} else if err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+
+		setTenant(c, org.ID)
+		c.Set("tenantFromHeader", header != "")
+		c.Next()
+	}
+}
+
+// scopeToTokenOrganisation scopes an authenticated request to the organisation its token was
+// issued for. A Tenant header naming another organisation is rejected rather than ignored.
+func scopeToTokenOrganisation(c *gin.Context, organisationID primitive.ObjectID) bool {
+	if c.GetBool("tenantFromHeader") && c.GetString("organisationId") != organisationID.Hex() {
+		api.Error(c, http.StatusForbidden, "Token was not issued for this organisation", nil)
+		return false
+	}
+
+	setTenant(c, organisationID)
+	return true
+}
+
+func setTenant(c *gin.Context, organisationID primitive.ObjectID) {
+	c.Request = c.Request.WithContext(tenant.WithOrganisation(c.Request.Context(), organisationID))
+	c.Set("organisationId", organisationID.Hex())
+}
+
+// RequireBootstrapOrganisation limits a route to the organisation created by the first-run
+// setup. It guards the integrations configured for the whole deployment, like SCIM and the
+// directory sync, which must not reach into the other organisations. It must run after the
+// auth middleware on authenticated routes.
+func RequireBootstrapOrganisation() gin.HandlerFunc {
+	orgRepo := organisation.NewOrganisationRepository()
+
+	return func(c *gin.Context) {
+		org, err := orgRepo.GetBootstrapOrganisation(c.Request.Context())
+		if err != nil && err != mongo.ErrNoDocuments {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+
+		if err == mongo.ErrNoDocuments || c.GetString("organisationId") != org.ID.Hex() {
+			api.Error(c, http.StatusNotFound, "Not available for this organisation", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
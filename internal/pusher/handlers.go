@@ -0,0 +1,53 @@
+package pusher
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/api"
+)
+
+// @Summary Authorize a notification channel
+// @Description Sign a Pusher subscription to the private channel of the current user. Pusher clients call it with the socket_id and channel_name form fields and expect the signature as the whole response.
+// @Tags notifications
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param socket_id formData string true "Pusher socket ID"
+// @Param channel_name formData string true "Channel to subscribe to, private-user-<id> of the current user"
+// @Success 200 {object} map[string]interface{} "Channel authorization"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 403 {object} map[string]interface{} "The channel belongs to another user"
+// @Failure 404 {object} map[string]interface{} "Notifications are not configured"
+// @Security BearerAuth
+// @Router /pusher/auth [post]
+func AuthorizeChannel(c *gin.Context) {
+	if Client == nil {
+		api.Error(c, http.StatusNotFound, "Notifications are not configured", nil)
+		return
+	}
+
+	params, err := c.GetRawData()
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	form, err := url.ParseQuery(string(params))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid request format", nil)
+		return
+	}
+
+	if form.Get("channel_name") != UserChannel(c.GetString("userId")) {
+		api.Error(c, http.StatusForbidden, "The channel belongs to another user", nil)
+		return
+	}
+
+	response, err := Client.AuthorizePrivateChannel(params)
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
@@ -1,6 +1,7 @@
 package pusher
 
 import (
+	"log"
 	"one-to-one/internal/config"
 
 	"github.com/pusher/pusher-http-go/v5"
@@ -9,8 +10,9 @@ import (
 const OneToOneChannel = "one-to-one-channel"
 
 const (
-	ReceivedMessage string = "received-message"
-	WantsToChat     string = "wants-to-chat"
+	ReceivedMessage      string = "received-message"
+	WantsToChat          string = "wants-to-chat"
+	ReportingLineRequest string = "reporting-line-request"
 )
 
 var Client *pusher.Client
@@ -24,3 +26,21 @@ func Init() {
 		Secure:  true,
 	}
 }
+
+// UserChannel is the channel of the notifications meant for one user. Its events only carry
+// IDs; the details are fetched from the API. It is a private channel, which only the user is
+// allowed to subscribe to, see AuthorizeChannel.
+func UserChannel(userID string) string {
+	return "private-user-" + userID
+}
+
+// Notify triggers an event. A notification that cannot be delivered is logged rather than
+// failing the request that caused it.
+func Notify(channel string, event string, data interface{}) {
+	if Client == nil {
+		return
+	}
+	if err := Client.Trigger(channel, event, data); err != nil {
+		log.Println("Failed to send notification: ", err)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+)
+
+// GROUP: /audit
+func AuditRoutes(group *gin.Engine) {
+	auditRepo := audit.NewAuditRepository()
+	auditHandler := audit.NewAuditHandler(auditRepo)
+
+	auditGroup := group.Group("/audit")
+
+	// --- ADMIN ROUTES ---
+	auditGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequireMFA(), middleware.ForbidImpersonation(), middleware.RequirePermission(auth.PermissionReadAudit))
+	{
+		auditGroup.GET("", func(c *gin.Context) {
+			auditHandler.GetEntries(c)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"one-to-one/internal/config"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/oidc"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GROUP: /auth
+func AuthRoutes(group *gin.Engine) {
+	userRepo := user.NewUserRepository()
+	authRepo := auth.NewAuthRepository()
+
+	oidcConfig := config.AppConfig().OIDC
+	oidcClient := oidc.NewClient(oidcConfig.IssuerURL, oidcConfig.ClientID, oidcConfig.ClientSecret, oidcConfig.RedirectURL, oidcConfig.Scopes)
+	oidcHandler := oidc.NewOIDCHandler(oidcClient, userRepo, authRepo, organisation.NewOrganisationRepository())
+
+	authGroup := group.Group("/auth")
+
+	// --- PUBLIC ROUTES ---
+	// The identity provider is configured for the whole deployment, its users only belong to the
+	// bootstrap organisation.
+	authGroup.GET("/oidc/login", middleware.RequireBootstrapOrganisation(), func(c *gin.Context) {
+		oidcHandler.Login(c)
+	})
+
+	authGroup.GET("/oidc/callback", middleware.RequireBootstrapOrganisation(), func(c *gin.Context) {
+		oidcHandler.Callback(c)
+	})
+}
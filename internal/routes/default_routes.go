@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"one-to-one/internal/api"
 	"one-to-one/internal/config"
+	"one-to-one/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,4 +17,17 @@ func DefaultRoutes(group *gin.Engine) {
 			"version": config.AppConfig().App.AppVersion,
 		})
 	})
+
+	// Public keys for other services to verify our access tokens. The set is returned as is,
+	// without the usual response envelope, since JWKS consumers expect the standard format.
+	group.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		keys, err := middleware.JSONWebKeySet()
+		if err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": keys})
+	})
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/directory"
+	"one-to-one/internal/services/user"
+)
+
+// GROUP: /directory
+func DirectoryRoutes(group *gin.Engine) {
+	syncer := directory.NewSyncer(directory.NewLDAPDirectory(), user.NewUserRepository(), auth.NewAuthRepository())
+	directoryHandler := directory.NewDirectoryHandler(syncer)
+
+	directoryGroup := group.Group("/directory")
+
+	// --- CRON ROUTES ---
+	directoryGroup.GET("/sync", middleware.CronAuthMiddleware(), middleware.RequireBootstrapOrganisation(), func(c *gin.Context) {
+		directoryHandler.RunScheduledSync(c)
+	})
+
+	// --- ADMIN ROUTES ---
+	directoryGroup.POST("/sync", middleware.JWTAuthMiddleware(), middleware.RequireMFA(), middleware.ForbidImpersonation(), middleware.RequirePermission(auth.PermissionSyncUsers), middleware.RequireBootstrapOrganisation(), func(c *gin.Context) {
+		directoryHandler.Sync(c)
+	})
+}
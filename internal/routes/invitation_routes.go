@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/mailer"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/invitation"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+)
+
+// GROUP: /invitation
+func InvitationRoutes(group *gin.Engine) {
+	invitationRepo := invitation.NewInvitationRepository()
+	invitationHandler := invitation.NewInvitationHandler(invitationRepo, user.NewUserRepository(), auth.NewAuthRepository(), organisation.NewOrganisationRepository(), mailer.Client)
+
+	invitationGroup := group.Group("/invitation")
+
+	// --- PUBLIC ROUTES ---
+	invitationGroup.POST("/accept", func(c *gin.Context) {
+		invitationHandler.AcceptInvitation(c)
+	})
+
+	// --- PROTECTED ROUTES ---
+	invitationGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequireMFA(), middleware.ForbidImpersonation(), middleware.RequirePermission(auth.PermissionInviteUsers))
+	{
+		invitationGroup.POST("", func(c *gin.Context) {
+			invitationHandler.CreateInvitation(c)
+		})
+
+		invitationGroup.GET("", func(c *gin.Context) {
+			invitationHandler.GetInvitations(c)
+		})
+
+		invitationGroup.DELETE("/:id", func(c *gin.Context) {
+			invitationHandler.RevokeInvitation(c)
+		})
+	}
+}
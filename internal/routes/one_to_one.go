@@ -2,7 +2,9 @@ package routes
 
 import (
 	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
 	one_to_one "one-to-one/internal/services/one-to-one"
+	"one-to-one/internal/services/user"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,43 +12,49 @@ import (
 // GROUP: /one-to-one
 func OneToOneRoutes(group *gin.Engine) {
 	oneToOneRepo := one_to_one.NewOneToOneRepository()
-	oneToOneHandler := one_to_one.NewOneToOneHandler(oneToOneRepo)
+	oneToOneHandler := one_to_one.NewOneToOneHandler(oneToOneRepo, user.NewUserRepository())
 
 	oneToOneGroup := group.Group("/one-to-one")
 
 	// --- PROTECTED ROUTES ---
-	oneToOneGroup.Use(middleware.JWTAuthMiddleware())
+	oneToOneGroup.Use(middleware.TokenAuthMiddleware(), middleware.RequireMFA())
 	{
-		oneToOneGroup.POST("/create", func(c *gin.Context) {
+		oneToOneGroup.POST("/create", middleware.RequireScope(auth.ScopeReportsWrite), func(c *gin.Context) {
 			oneToOneHandler.CreateWeeklyReport(c)
 		})
 
 		// --- REPORTEE ROUTES ---
 
-		oneToOneGroup.GET("/reportee/all", func(c *gin.Context) {
+		oneToOneGroup.GET("/reportee/all", middleware.RequireScope(auth.ScopeReportsRead), func(c *gin.Context) {
 			oneToOneHandler.GetAllWeeklyReportsForReportee(c)
 		})
 
-		oneToOneGroup.GET("/reportee", func(c *gin.Context) {
+		oneToOneGroup.GET("/reportee", middleware.RequireScope(auth.ScopeReportsRead), func(c *gin.Context) {
 			oneToOneHandler.GetWeeklyReportByWeekAndYearForReportee(c)
 		})
 
-		oneToOneGroup.PUT("/reportee/update", func(c *gin.Context) {
+		oneToOneGroup.PUT("/reportee/update", middleware.RequireScope(auth.ScopeReportsWrite), func(c *gin.Context) {
 			oneToOneHandler.UpdateWeeklyReportForReportee(c)
 		})
 
 		// --- REPORT TO ROUTES ---
 
-		oneToOneGroup.GET("/report-to/all", func(c *gin.Context) {
+		oneToOneGroup.GET("/report-to/all", middleware.RequireScope(auth.ScopeReportsRead), func(c *gin.Context) {
 			oneToOneHandler.GetAllWeeklyReportsForReportTo(c)
 		})
 
-		oneToOneGroup.GET("/report-to", func(c *gin.Context) {
+		oneToOneGroup.GET("/report-to", middleware.RequireScope(auth.ScopeReportsRead), func(c *gin.Context) {
 			oneToOneHandler.GetWeeklyReportByWeekAndYearForReportTo(c)
 		})
 
-		oneToOneGroup.PUT("/report-to/update", func(c *gin.Context) {
+		oneToOneGroup.PUT("/report-to/update", middleware.RequireScope(auth.ScopeReportsWrite), func(c *gin.Context) {
 			oneToOneHandler.UpdateWeeklyReportForReportTo(c)
 		})
+
+		// --- SKIP-LEVEL ROUTES ---
+
+		oneToOneGroup.GET("/skip-level/all", middleware.RequireScope(auth.ScopeReportsRead), func(c *gin.Context) {
+			oneToOneHandler.GetAllWeeklyReportsForSkipLevel(c)
+		})
 	}
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+)
+
+// GROUP: /organisation
+func OrganisationRoutes(group *gin.Engine) {
+	orgHandler := organisation.NewOrganisationHandler(organisation.NewOrganisationRepository())
+
+	orgGroup := group.Group("/organisation")
+
+	// --- PROTECTED ROUTES ---
+	orgGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequireMFA())
+	{
+		orgGroup.GET("", func(c *gin.Context) {
+			orgHandler.GetOrganisation(c)
+		})
+
+		orgGroup.PUT("", middleware.ForbidImpersonation(), middleware.RequirePermission(auth.PermissionManageOrg), func(c *gin.Context) {
+			orgHandler.UpdateOrganisation(c)
+		})
+	}
+}
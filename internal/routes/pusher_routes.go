@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/pusher"
+)
+
+// GROUP: /pusher
+func PusherRoutes(group *gin.Engine) {
+	pusherGroup := group.Group("/pusher")
+
+	// --- PROTECTED ROUTES ---
+	pusherGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequireMFA())
+	{
+		pusherGroup.POST("/auth", func(c *gin.Context) {
+			pusher.AuthorizeChannel(c)
+		})
+	}
+}
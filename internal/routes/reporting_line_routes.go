@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/reportingline"
+	"one-to-one/internal/services/user"
+)
+
+// GROUP: /reporting-line
+func ReportingLineRoutes(group *gin.Engine) {
+	reportingLineRepo := reportingline.NewReportingLineRepository()
+	reportingLineHandler := reportingline.NewReportingLineHandler(reportingLineRepo, user.NewUserRepository(), audit.NewAuditRepository())
+
+	reportingLineGroup := group.Group("/reporting-line")
+
+	// --- PROTECTED ROUTES ---
+	reportingLineGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequireMFA())
+	{
+		reportingLineGroup.GET("/requests", func(c *gin.Context) {
+			reportingLineHandler.GetRequests(c)
+		})
+
+		reportingLineGroup.POST("/requests/:id/accept", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			reportingLineHandler.AcceptRequest(c)
+		})
+
+		reportingLineGroup.POST("/requests/:id/decline", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			reportingLineHandler.DeclineRequest(c)
+		})
+
+		reportingLineGroup.POST("/requests/:id/cancel", func(c *gin.Context) {
+			reportingLineHandler.CancelRequest(c)
+		})
+
+		// --- ADMIN ROUTES ---
+
+		reportingLineGroup.GET("/requests/pending", middleware.RequirePermission(auth.PermissionManageReportingLines), func(c *gin.Context) {
+			reportingLineHandler.GetPendingRequests(c)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/scim"
+	"one-to-one/internal/services/user"
+)
+
+// GROUP: /scim/v2
+func SCIMRoutes(group *gin.Engine) {
+	scimHandler := scim.NewSCIMHandler(user.NewUserRepository(), auth.NewAuthRepository())
+
+	scimGroup := group.Group("/scim/v2")
+
+	// --- PROVISIONING CLIENT ROUTES ---
+	scimGroup.Use(scim.RequireToken(), middleware.RequireBootstrapOrganisation())
+	{
+		scimGroup.GET("/ServiceProviderConfig", func(c *gin.Context) {
+			scimHandler.GetServiceProviderConfig(c)
+		})
+
+		scimGroup.GET("/Users", func(c *gin.Context) {
+			scimHandler.GetUsers(c)
+		})
+
+		scimGroup.POST("/Users", func(c *gin.Context) {
+			scimHandler.CreateUser(c)
+		})
+
+		scimGroup.GET("/Users/:id", func(c *gin.Context) {
+			scimHandler.GetUser(c)
+		})
+
+		scimGroup.PUT("/Users/:id", func(c *gin.Context) {
+			scimHandler.ReplaceUser(c)
+		})
+
+		scimGroup.PATCH("/Users/:id", func(c *gin.Context) {
+			scimHandler.PatchUser(c)
+		})
+
+		scimGroup.DELETE("/Users/:id", func(c *gin.Context) {
+			scimHandler.DeleteUser(c)
+		})
+	}
+}
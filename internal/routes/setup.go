@@ -2,18 +2,54 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"one-to-one/internal/middleware"
 )
 
 func SetupRoutes(router *gin.Engine) {
+	// Repositories read the organisation of a request from its context. With the fallback, the
+	// gin context passed to them sees the values of the request context.
+	router.ContextWithFallback = true
+	router.Use(middleware.TenantMiddleware())
+
 	// Swagger routes for API documentation
 	SwaggerRoutes(router)
 
 	// Default routes for the root path
 	DefaultRoutes(router)
 
+	// First-run setup routes for the /setup path
+	SetupFlowRoutes(router)
+
+	// Organisation routes for the /organisation path
+	OrganisationRoutes(router)
+
 	// User routes for the /user path
 	UserRoutes(router)
 
+	// Authentication routes for the /auth path
+	AuthRoutes(router)
+
 	// One-to-one routes for the /one-to-one path
 	OneToOneRoutes(router)
+
+	// Invitation routes for the /invitation path
+	InvitationRoutes(router)
+
+	// Reporting line change request routes for the /reporting-line path
+	ReportingLineRoutes(router)
+
+	// SCIM provisioning routes for the /scim/v2 path
+	SCIMRoutes(router)
+
+	// Directory sync routes for the /directory path
+	DirectoryRoutes(router)
+
+	// Audit log routes for the /audit path
+	AuditRoutes(router)
+
+	// Notification channel authorization for the /pusher path
+	PusherRoutes(router)
+
+	// Uploaded files of the local storage backend for the /uploads path
+	StorageRoutes(router)
 }
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/setup"
+	"one-to-one/internal/services/user"
+)
+
+// GROUP: /setup
+func SetupFlowRoutes(group *gin.Engine) {
+	orgRepo := organisation.NewOrganisationRepository()
+	setupHandler := setup.NewSetupHandler(orgRepo, user.NewUserRepository(), auth.NewAuthRepository())
+
+	setupGroup := group.Group("/setup")
+
+	// --- PUBLIC ROUTES ---
+	setupGroup.GET("", func(c *gin.Context) {
+		setupHandler.GetStatus(c)
+	})
+
+	setupGroup.POST("", func(c *gin.Context) {
+		setupHandler.Setup(c)
+	})
+
+	setupGroup.POST("/organisation", func(c *gin.Context) {
+		setupHandler.CreateOrganisation(c)
+	})
+}
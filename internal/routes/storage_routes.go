@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/storage"
+)
+
+// GROUP: /uploads
+func StorageRoutes(group *gin.Engine) {
+	// Only the files of the local backend are served by the API, S3 serves its own.
+	if local, ok := storage.Client.(*storage.LocalStorage); ok {
+		group.Static(storage.LocalURLPath, local.Dir())
+	}
+}
@@ -2,14 +2,24 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"one-to-one/internal/mailer"
 	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/reportingline"
 	"one-to-one/internal/services/user"
+	"one-to-one/internal/storage"
 )
 
 // GROUP: /user
 func UserRoutes(group *gin.Engine) {
 	userRepo := user.NewUserRepository()
-	userHandler := user.NewUserHandler(userRepo)
+	authRepo := auth.NewAuthRepository()
+	auditRepo := audit.NewAuditRepository()
+	orgRepo := organisation.NewOrganisationRepository()
+	userHandler := user.NewUserHandler(userRepo, authRepo, auditRepo, orgRepo, mailer.Client, storage.Client)
+	reportingLineHandler := reportingline.NewReportingLineHandler(reportingline.NewReportingLineRepository(), userRepo, auditRepo)
 
 	userGroup := group.Group("/user")
 
@@ -18,35 +28,159 @@ func UserRoutes(group *gin.Engine) {
 		userHandler.CreateUser(c)
 	})
 
-	userGroup.GET("/all", func(c *gin.Context) {
-		userHandler.GetAllUsers(c)
+	userGroup.POST("/login", func(c *gin.Context) {
+		userHandler.LoginUser(c)
 	})
 
-	userGroup.GET("/email/:email", func(c *gin.Context) {
-		userHandler.GetUserByEmail(c)
+	userGroup.POST("/login/mfa", func(c *gin.Context) {
+		userHandler.LoginMFA(c)
 	})
 
-	userGroup.POST("/login", func(c *gin.Context) {
-		userHandler.LoginUser(c)
+	userGroup.POST("/token/refresh", func(c *gin.Context) {
+		userHandler.RefreshToken(c)
+	})
+
+	userGroup.POST("/password/forgot", func(c *gin.Context) {
+		userHandler.ForgotPassword(c)
+	})
+
+	userGroup.POST("/password/reset", func(c *gin.Context) {
+		userHandler.ResetPassword(c)
+	})
+
+	userGroup.POST("/email/verify", func(c *gin.Context) {
+		userHandler.VerifyEmail(c)
+	})
+
+	userGroup.POST("/email/verify/resend", func(c *gin.Context) {
+		userHandler.ResendVerificationEmail(c)
 	})
 
 	// --- PROTECTED ROUTES ---
 	userGroup.Use(middleware.JWTAuthMiddleware())
+
+	// Users who have to enroll in two-factor authentication can only reach these routes until they have.
+	userGroup.GET("/current", func(c *gin.Context) {
+		userHandler.GetCurrentUser(c)
+	})
+
+	userGroup.POST("/logout", func(c *gin.Context) {
+		userHandler.Logout(c)
+	})
+
+	userGroup.POST("/mfa/enroll", middleware.ForbidImpersonation(), func(c *gin.Context) {
+		userHandler.EnrollMFA(c)
+	})
+
+	userGroup.POST("/mfa/activate", middleware.ForbidImpersonation(), func(c *gin.Context) {
+		userHandler.ActivateMFA(c)
+	})
+
+	userGroup.Use(middleware.RequireMFA())
 	{
-		userGroup.GET("/current", func(c *gin.Context) {
-			userHandler.GetCurrentUser(c)
+		userGroup.GET("/all", middleware.RequirePermission(auth.PermissionListUsers), func(c *gin.Context) {
+			userHandler.GetAllUsers(c)
+		})
+
+		userGroup.GET("/email/:email", func(c *gin.Context) {
+			userHandler.GetUserByEmail(c)
+		})
+
+		userGroup.PATCH("/current", func(c *gin.Context) {
+			userHandler.UpdateCurrentUser(c)
+		})
+
+		userGroup.POST("/current/avatar", func(c *gin.Context) {
+			userHandler.UploadAvatar(c)
+		})
+
+		userGroup.DELETE("/current/avatar", func(c *gin.Context) {
+			userHandler.DeleteAvatar(c)
+		})
+
+		userGroup.POST("/logout/all", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.LogoutEverywhere(c)
+		})
+
+		userGroup.POST("/password/change", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.ChangePassword(c)
 		})
 
+		// --- SESSION ROUTES ---
+
+		userGroup.GET("/sessions", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.GetSessions(c)
+		})
+
+		userGroup.DELETE("/sessions/:id", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.RevokeSession(c)
+		})
+
+		// --- TWO-FACTOR ROUTES ---
+
+		userGroup.POST("/mfa/disable", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.DisableMFA(c)
+		})
+
+		userGroup.POST("/mfa/recovery-codes", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.RegenerateRecoveryCodes(c)
+		})
+
+		// --- PERSONAL ACCESS TOKEN ROUTES ---
+
+		userGroup.POST("/tokens", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.CreatePersonalAccessToken(c)
+		})
+
+		userGroup.GET("/tokens", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.GetPersonalAccessTokens(c)
+		})
+
+		userGroup.DELETE("/tokens/:id", middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.RevokePersonalAccessToken(c)
+		})
+
+		userGroup.GET("/:id/org-chart", func(c *gin.Context) {
+			userHandler.GetOrgChart(c)
+		})
+
+		userGroup.GET("/:id/managers", func(c *gin.Context) {
+			userHandler.GetManagerHistory(c)
+		})
+
+		// Reporting line changes only take effect once accepted, see /reporting-line.
 		userGroup.POST("/reportee/add", func(c *gin.Context) {
-			userHandler.AddReportee(c)
+			reportingLineHandler.RequestReportee(c)
 		})
 
 		userGroup.POST("/reportee/remove", func(c *gin.Context) {
-			userHandler.RemoveReportee(c)
+			reportingLineHandler.RequestReporteeRemoval(c)
 		})
 
 		userGroup.POST("/reports-to/add", func(c *gin.Context) {
-			userHandler.AddReportsToUser(c)
+			reportingLineHandler.RequestManager(c)
+		})
+
+		// --- ADMIN ROUTES ---
+
+		userGroup.GET("/roles", middleware.RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+			userHandler.GetRoles(c)
+		})
+
+		userGroup.PATCH("/:id", middleware.RequirePermission(auth.PermissionEditUsers), func(c *gin.Context) {
+			userHandler.UpdateUser(c)
+		})
+
+		userGroup.PUT("/:id/roles", middleware.RequirePermission(auth.PermissionManageRoles), func(c *gin.Context) {
+			userHandler.UpdateUserRoles(c)
+		})
+
+		userGroup.POST("/:id/unlock", middleware.RequirePermission(auth.PermissionUnlockUsers), func(c *gin.Context) {
+			userHandler.UnlockUser(c)
+		})
+
+		userGroup.POST("/:id/impersonate", middleware.RequirePermission(auth.PermissionImpersonate), middleware.ForbidImpersonation(), func(c *gin.Context) {
+			userHandler.ImpersonateUser(c)
 		})
 	}
 
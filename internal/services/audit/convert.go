@@ -0,0 +1,22 @@
+package audit
+
+import "one-to-one/pkg/utils"
+
+func ConvertEntryToEntryResponse(entry Entry) EntryResponse {
+	var subjectID *string
+	if entry.SubjectID != nil {
+		subjectID = utils.StringPtr(entry.SubjectID.Hex())
+	}
+
+	return EntryResponse{
+		ID:           entry.ID.Hex(),
+		Action:       entry.Action,
+		ActorID:      entry.ActorID.Hex(),
+		ActorEmail:   entry.ActorEmail,
+		SubjectID:    subjectID,
+		SubjectEmail: entry.SubjectEmail,
+		IP:           entry.IP,
+		Details:      entry.Details,
+		CreatedAt:    entry.CreatedAt.Time(),
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultEntryLimit = 100
+	maxEntryLimit     = 1000
+)
+
+type AuditHandler struct {
+	Repo AuditRepository
+}
+
+func NewAuditHandler(repo AuditRepository) *AuditHandler {
+	return &AuditHandler{Repo: repo}
+}
+
+// @Summary Get audit log entries
+// @Description List audit log entries, most recent first
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param actorId query string false "Only entries by this user"
+// @Param subjectId query string false "Only entries about this user"
+// @Param action query string false "Only entries with this action, e.g. impersonation.request"
+// @Param limit query int false "Maximum number of entries, 100 by default"
+// @Success 200 {array} EntryResponse "Audit log entries"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *AuditHandler) GetEntries(c *gin.Context) {
+	filter := EntryFilter{Action: c.Query("action"), Limit: defaultEntryLimit}
+
+	if actorID := c.Query("actorId"); actorID != "" {
+		id, err := primitive.ObjectIDFromHex(actorID)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, "Invalid actorId", nil)
+			return
+		}
+		filter.ActorID = &id
+	}
+
+	if subjectID := c.Query("subjectId"); subjectID != "" {
+		id, err := primitive.ObjectIDFromHex(subjectID)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, "Invalid subjectId", nil)
+			return
+		}
+		filter.SubjectID = &id
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > maxEntryLimit {
+			api.Error(c, http.StatusBadRequest, "Invalid limit", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.Repo.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	response := make([]EntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = ConvertEntryToEntryResponse(entry)
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved audit log successfully", response)
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionImpersonationStart   = "impersonation.start"
+	ActionImpersonationRequest = "impersonation.request"
+
+	ActionReportingLineRequest = "reporting-line.request"
+	ActionReportingLineAccept  = "reporting-line.accept"
+	ActionReportingLineDecline = "reporting-line.decline"
+	ActionReportingLineCancel  = "reporting-line.cancel"
+)
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+type EntryResponse struct {
+	ID           string                 `json:"id"`
+	Action       string                 `json:"action"`
+	ActorID      string                 `json:"actorId"`
+	ActorEmail   string                 `json:"actorEmail"`
+	SubjectID    *string                `json:"subjectId,omitempty"`
+	SubjectEmail string                 `json:"subjectEmail,omitempty"`
+	IP           string                 `json:"ip,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ MONGO OBJECTS ------------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// Entry records who did what to whom. ActorID is the person at the keyboard, so while an admin
+// impersonates a user the actor is the admin and the subject is the impersonated user.
+type Entry struct {
+	ID             primitive.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID     `json:"-" bson:"organisationId,omitempty"`
+	Action         string                 `json:"action" bson:"action"`
+	ActorID        primitive.ObjectID     `json:"actorId" bson:"actorId"`
+	ActorEmail     string                 `json:"actorEmail" bson:"actorEmail"`
+	SubjectID      *primitive.ObjectID    `json:"subjectId,omitempty" bson:"subjectId,omitempty"`
+	SubjectEmail   string                 `json:"subjectEmail,omitempty" bson:"subjectEmail,omitempty"`
+	IP             string                 `json:"ip,omitempty" bson:"ip,omitempty"`
+	Details        map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt      primitive.DateTime     `json:"createdAt" bson:"createdAt"`
+}
+
+// EntryFilter narrows down GetEntries. Zero fields are ignored.
+type EntryFilter struct {
+	ActorID   *primitive.ObjectID
+	SubjectID *primitive.ObjectID
+	Action    string
+	Limit     int64
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"one-to-one/internal/db"
+	"one-to-one/internal/tenant"
+)
+
+type AuditRepository interface {
+	Record(c context.Context, entry Entry) error
+	GetEntries(c context.Context, filter EntryFilter) ([]Entry, error)
+}
+
+type repositoryImpl struct {
+	collection *mongo.Collection
+}
+
+var indexesOnce sync.Once
+
+func NewAuditRepository() AuditRepository {
+	r := &repositoryImpl{
+		collection: db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_AUDIT_LOG),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "subjectId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create audit indexes: ", err)
+		}
+	})
+
+	return r
+}
+
+// Record appends an entry to the audit log of the organisation of the context. Entries are never
+// updated or deleted.
+func (r *repositoryImpl) Record(c context.Context, entry Entry) error {
+	if organisationID, ok := tenant.OrganisationID(c); ok {
+		entry.OrganisationID = organisationID
+	}
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt == 0 {
+		entry.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	}
+
+	_, err := r.collection.InsertOne(c, entry)
+	return err
+}
+
+// GetEntries returns the matching entries of the organisation of the context, most recent first.
+func (r *repositoryImpl) GetEntries(c context.Context, filter EntryFilter) ([]Entry, error) {
+	query := tenant.Filter(c, bson.M{})
+	if filter.ActorID != nil {
+		query["actorId"] = *filter.ActorID
+	}
+	if filter.SubjectID != nil {
+		query["subjectId"] = *filter.SubjectID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+
+	cursor, err := r.collection.Find(c, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	entries := []Entry{}
+	if err := cursor.All(c, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"one-to-one/internal/config"
+	"one-to-one/internal/tenant"
+)
+
+// maxLockoutDoublings bounds the exponent of the backoff so the shift cannot overflow.
+const maxLockoutDoublings = 20
+
+// AccountLoginKey identifies the failed login counter of an account in the organisation of the
+// context, as email addresses are only unique within one. Unknown emails get a counter too, so
+// locked and unknown accounts cannot be told apart.
+func AccountLoginKey(c context.Context, email string) string {
+	organisationID, _ := tenant.OrganisationID(c)
+	return "account:" + organisationID.Hex() + ":" + strings.ToLower(email)
+}
+
+// IPLoginKey identifies the failed login counter of a client IP address.
+func IPLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginLockedUntil returns the time until which logins for any of the keys are blocked, or the
+// zero time when none of them is locked.
+func LoginLockedUntil(c context.Context, repo AuthRepository, keys ...string) (time.Time, error) {
+	attempts, err := repo.GetLoginAttempts(c, keys)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var lockedUntil time.Time
+	now := time.Now()
+	for _, attempt := range attempts {
+		if attempt.LockedUntil == nil {
+			continue
+		}
+		if until := attempt.LockedUntil.Time(); until.After(now) && until.After(lockedUntil) {
+			lockedUntil = until
+		}
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure counts a failed login against the account and the IP address, and locks
+// whichever of them went over its limit. Every failure past the limit doubles the lockout.
+func RecordLoginFailure(c context.Context, repo AuthRepository, email string, ip string) error {
+	authConfig := config.AppConfig().Auth
+
+	if err := recordFailure(c, repo, AccountLoginKey(c, email), authConfig.MaxLoginAttempts); err != nil {
+		return err
+	}
+	return recordFailure(c, repo, IPLoginKey(ip), authConfig.MaxLoginAttemptsPerIP)
+}
+
+// ClearAccountLoginFailures resets the counter of an account, after a successful login or when
+// an administrator unlocks it. IP counters are left to expire so that a single valid account
+// cannot be used to reset them.
+func ClearAccountLoginFailures(c context.Context, repo AuthRepository, email string) error {
+	return repo.ClearLoginAttempts(c, AccountLoginKey(c, email))
+}
+
+func recordFailure(c context.Context, repo AuthRepository, key string, limit int) error {
+	window := time.Minute * time.Duration(config.AppConfig().Auth.LoginAttemptWindow)
+
+	attempt, err := repo.IncrementLoginFailures(c, key, time.Now().Add(window))
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures < limit {
+		return nil
+	}
+
+	return repo.LockLogin(c, key, time.Now().Add(lockoutDuration(attempt.Failures-limit)))
+}
+
+// lockoutDuration returns the lockout after the given number of failures past the limit.
+func lockoutDuration(excess int) time.Duration {
+	authConfig := config.AppConfig().Auth
+	base := time.Minute * time.Duration(authConfig.LoginLockout)
+	max := time.Minute * time.Duration(authConfig.MaxLoginLockout)
+
+	if excess > maxLockoutDoublings {
+		return max
+	}
+	if lockout := base << uint(excess); lockout < max {
+		return lockout
+	}
+	return max
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ MONGO OBJECTS ------------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// RefreshToken is a server-side record of an issued refresh token. Only the hash of the token is
+// stored. Every token issued from the same login shares a FamilyID so that the whole chain can be
+// revoked when a rotated token is presented again. MFA records whether that login passed the
+// second factor, so that refreshed access tokens keep the same assurance. OrganisationID is the
+// organisation of the user, since the refresh request itself does not have to name it.
+type RefreshToken struct {
+	ID             primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID  `json:"-" bson:"organisationId,omitempty"`
+	UserID         primitive.ObjectID  `json:"userId" bson:"userId"`
+	FamilyID       primitive.ObjectID  `json:"familyId" bson:"familyId"`
+	TokenHash      string              `json:"-" bson:"tokenHash"`
+	MFA            bool                `json:"mfa" bson:"mfa"`
+	ExpiresAt      primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	UsedAt         *primitive.DateTime `json:"usedAt,omitempty" bson:"usedAt,omitempty"`
+	RevokedAt      *primitive.DateTime `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+	CreatedAt      primitive.DateTime  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// RevokedToken blocks access tokens before they expire. A record either names a single token
+// through its JTI, or covers every token of UserID issued at or before RevokedBefore. Records
+// are removed by a TTL index once the tokens they cover would have expired anyway.
+type RevokedToken struct {
+	ID            primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	JTI           string              `json:"jti,omitempty" bson:"jti,omitempty"`
+	UserID        *primitive.ObjectID `json:"userId,omitempty" bson:"userId,omitempty"`
+	RevokedBefore *primitive.DateTime `json:"revokedBefore,omitempty" bson:"revokedBefore,omitempty"`
+	SessionID     *primitive.ObjectID `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	ExpiresAt     primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt     primitive.DateTime  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their password.
+type PasswordResetToken struct {
+	ID        primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID  `json:"userId" bson:"userId"`
+	TokenHash string              `json:"-" bson:"tokenHash"`
+	ExpiresAt primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	UsedAt    *primitive.DateTime `json:"usedAt,omitempty" bson:"usedAt,omitempty"`
+	CreatedAt primitive.DateTime  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// OIDCState holds what is needed to finish an OpenID Connect login started by this server:
+// the PKCE code verifier and the nonce expected in the ID token. It is looked up by the hash
+// of the state parameter and can only be used once.
+type OIDCState struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID `json:"-" bson:"organisationId,omitempty"` // the organisation the login was started for
+	StateHash      string             `json:"-" bson:"stateHash"`
+	Nonce          string             `json:"-" bson:"nonce"`
+	CodeVerifier   string             `json:"-" bson:"codeVerifier"`
+	ExpiresAt      primitive.DateTime `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt      primitive.DateTime `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// PersonalAccessToken lets scripts and integrations call the API on behalf of a user with a
+// limited set of scopes. Only the hash of the token is stored, Prefix is kept to help users
+// tell their tokens apart. MFA records whether the session that created the token passed the
+// second factor, and is carried over to every request made with it.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID  `json:"userId" bson:"userId"`
+	Name       string              `json:"name" bson:"name"`
+	Prefix     string              `json:"prefix" bson:"prefix"`
+	TokenHash  string              `json:"-" bson:"tokenHash"`
+	Scopes     []string            `json:"scopes" bson:"scopes"`
+	MFA        bool                `json:"mfa" bson:"mfa"`
+	ExpiresAt  *primitive.DateTime `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	LastUsedAt *primitive.DateTime `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	RevokedAt  *primitive.DateTime `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+	CreatedAt  primitive.DateTime  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+}
+
+// Principal is the part of a user needed to authenticate a request.
+type Principal struct {
+	ID             primitive.ObjectID  `bson:"_id"`
+	OrganisationID primitive.ObjectID  `bson:"organisationId"`
+	Email          string              `bson:"email"`
+	EmailVerified  bool                `bson:"emailVerified"`
+	Roles          []string            `bson:"roles"`
+	DeactivatedAt  *primitive.DateTime `bson:"deactivatedAt"`
+}
+
+// LoginAttempt counts the recent failed logins for an account or an IP address. Key is built
+// by AccountLoginKey or IPLoginKey.
+type LoginAttempt struct {
+	ID            primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Key           string              `json:"key" bson:"key"`
+	Failures      int                 `json:"failures" bson:"failures"`
+	LockedUntil   *primitive.DateTime `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"`
+	LastFailureAt primitive.DateTime  `json:"lastFailureAt" bson:"lastFailureAt"`
+	ExpiresAt     primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+}
+
+// Session is one login of a user on a device. It shares its ID with the refresh token family
+// started by the login, and access tokens carry it in their sid claim.
+type Session struct {
+	ID             primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID  `json:"-" bson:"organisationId,omitempty"`
+	UserID         primitive.ObjectID  `json:"userId" bson:"userId"`
+	UserAgent      string              `json:"userAgent" bson:"userAgent"`
+	IP             string              `json:"ip" bson:"ip"`
+	CreatedAt      primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+	LastSeenAt     primitive.DateTime  `json:"lastSeenAt" bson:"lastSeenAt"`
+	ExpiresAt      primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	RevokedAt      *primitive.DateTime `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
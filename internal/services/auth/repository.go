@@ -0,0 +1,596 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"one-to-one/internal/config"
+	"one-to-one/internal/db"
+)
+
+type AuthRepository interface {
+	CreateRefreshToken(c context.Context, token RefreshToken) error
+	GetRefreshTokenByHash(c context.Context, tokenHash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(c context.Context, id primitive.ObjectID) (bool, error)
+	RevokeRefreshTokenFamily(c context.Context, familyID primitive.ObjectID) error
+
+	RevokeToken(c context.Context, jti string, expiresAt time.Time) error
+	RevokeUserAccessTokens(c context.Context, userID primitive.ObjectID) error
+	RevokeAllUserTokens(c context.Context, userID primitive.ObjectID) error
+	IsTokenRevoked(c context.Context, jti string, userID primitive.ObjectID, sessionID primitive.ObjectID, issuedAt time.Time) (bool, error)
+
+	CreateSession(c context.Context, session Session) error
+	GetSessions(c context.Context, userID primitive.ObjectID) ([]Session, error)
+	RefreshSession(c context.Context, session Session) error
+	TouchSession(c context.Context, id primitive.ObjectID, ip string) error
+	RevokeSession(c context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+
+	CreatePasswordResetToken(c context.Context, token PasswordResetToken) error
+	GetPasswordResetToken(c context.Context, tokenHash string) (*PasswordResetToken, error)
+	ConsumePasswordResetToken(c context.Context, tokenHash string) (*PasswordResetToken, error)
+
+	CreateOIDCState(c context.Context, state OIDCState) error
+	ConsumeOIDCState(c context.Context, stateHash string) (*OIDCState, error)
+
+	CreatePersonalAccessToken(c context.Context, token PersonalAccessToken) error
+	GetPersonalAccessTokens(c context.Context, userID primitive.ObjectID) ([]PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(c context.Context, tokenHash string) (*PersonalAccessToken, error)
+	TouchPersonalAccessToken(c context.Context, id primitive.ObjectID) error
+	RevokePersonalAccessToken(c context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+
+	GetPrincipal(c context.Context, userID primitive.ObjectID) (*Principal, error)
+
+	GetLoginAttempts(c context.Context, keys []string) ([]LoginAttempt, error)
+	IncrementLoginFailures(c context.Context, key string, expiresAt time.Time) (*LoginAttempt, error)
+	LockLogin(c context.Context, key string, lockedUntil time.Time) error
+	ClearLoginAttempts(c context.Context, key string) error
+}
+
+type repositoryImpl struct {
+	refreshTokens       *mongo.Collection
+	revokedTokens       *mongo.Collection
+	passwordResetTokens *mongo.Collection
+	oidcStates          *mongo.Collection
+	accessTokens        *mongo.Collection
+	users               *mongo.Collection
+	loginAttempts       *mongo.Collection
+	sessions            *mongo.Collection
+}
+
+var indexesOnce sync.Once
+
+func NewAuthRepository() AuthRepository {
+	database := db.Client.Database(db.DATABASE_NAME)
+	r := &repositoryImpl{
+		refreshTokens:       database.Collection(db.COLLECTION_REFRESH_TOKEN),
+		revokedTokens:       database.Collection(db.COLLECTION_REVOKED_TOKEN),
+		passwordResetTokens: database.Collection(db.COLLECTION_PASSWORD_RESET_TOKEN),
+		oidcStates:          database.Collection(db.COLLECTION_OIDC_STATE),
+		accessTokens:        database.Collection(db.COLLECTION_PERSONAL_ACCESS_TOKEN),
+		users:               database.Collection(db.COLLECTION_USER),
+		loginAttempts:       database.Collection(db.COLLECTION_LOGIN_ATTEMPT),
+		sessions:            database.Collection(db.COLLECTION_SESSION),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := r.ensureIndexes(ctx); err != nil {
+			log.Println("Failed to create auth indexes: ", err)
+		}
+	})
+
+	return r
+}
+
+// ensureIndexes creates the lookup indexes and the TTL indexes that let MongoDB
+// purge expired tokens on its own.
+func (r *repositoryImpl) ensureIndexes(c context.Context) error {
+	_, err := r.refreshTokens.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.revokedTokens.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "sessionId", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "revokedBefore", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.passwordResetTokens.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.oidcStates.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "stateHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.accessTokens.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.loginAttempts.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.sessions.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "lastSeenAt", Value: -1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (r *repositoryImpl) CreateRefreshToken(c context.Context, token RefreshToken) error {
+	_, err := r.refreshTokens.InsertOne(c, token)
+	return err
+}
+
+func (r *repositoryImpl) GetRefreshTokenByHash(c context.Context, tokenHash string) (*RefreshToken, error) {
+	filter := bson.M{"tokenHash": tokenHash}
+
+	var token RefreshToken
+	err := r.refreshTokens.FindOne(c, filter).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed flags a refresh token as consumed. It reports false when the token had
+// already been used or revoked, which callers must treat as token reuse.
+func (r *repositoryImpl) MarkRefreshTokenUsed(c context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":       id,
+		"usedAt":    bson.M{"$exists": false},
+		"revokedAt": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"usedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	result, err := r.refreshTokens.UpdateOne(c, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token of a family along with the session it
+// belongs to, so the access tokens already handed out stop working as well.
+func (r *repositoryImpl) RevokeRefreshTokenFamily(c context.Context, familyID primitive.ObjectID) error {
+	filter := bson.M{"familyId": familyID, "revokedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revokedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	if _, err := r.refreshTokens.UpdateMany(c, filter, update); err != nil {
+		return err
+	}
+
+	_, err := r.sessions.UpdateOne(c, bson.M{"_id": familyID, "revokedAt": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+
+	return r.revokeSessionAccessTokens(c, familyID)
+}
+
+// revokeSessionAccessTokens invalidates the access tokens issued for a session.
+func (r *repositoryImpl) revokeSessionAccessTokens(c context.Context, sessionID primitive.ObjectID) error {
+	now := time.Now()
+	expiresAt := accessTokenMarkerExpiry(now)
+
+	_, err := r.revokedTokens.InsertOne(c, RevokedToken{
+		ID:        primitive.NewObjectID(),
+		SessionID: &sessionID,
+		ExpiresAt: primitive.NewDateTimeFromTime(expiresAt),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	})
+	return err
+}
+
+// accessTokenMarkerExpiry is when a marker revoking the access tokens issued until now can be
+// removed. It only has to outlive the tokens it covers, the longest lived of which are either
+// access or impersonation tokens.
+func accessTokenMarkerExpiry(now time.Time) time.Time {
+	lifetime := config.AppConfig().Auth.ShortTokenExpire
+	if impersonation := config.AppConfig().Auth.ImpersonationExpire; impersonation > lifetime {
+		lifetime = impersonation
+	}
+	return now.Add(time.Minute * time.Duration(lifetime)).Add(time.Minute)
+}
+
+func (r *repositoryImpl) RevokeToken(c context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.revokedTokens.InsertOne(c, RevokedToken{
+		ID:        primitive.NewObjectID(),
+		JTI:       jti,
+		ExpiresAt: primitive.NewDateTimeFromTime(expiresAt),
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	})
+	return err
+}
+
+// RevokeUserAccessTokens invalidates every access token issued to the user so far. Refresh
+// tokens stay valid, so clients pick up a fresh access token, e.g. after a role change.
+//
+// Tokens only carry their issue time in whole seconds. The marker is truncated to the second so
+// that tokens handed out right after it, such as after a password change, stay valid.
+func (r *repositoryImpl) RevokeUserAccessTokens(c context.Context, userID primitive.ObjectID) error {
+	now := time.Now()
+	revokedBefore := primitive.NewDateTimeFromTime(now.Truncate(time.Second))
+	expiresAt := accessTokenMarkerExpiry(now)
+
+	_, err := r.revokedTokens.InsertOne(c, RevokedToken{
+		ID:            primitive.NewObjectID(),
+		UserID:        &userID,
+		RevokedBefore: &revokedBefore,
+		ExpiresAt:     primitive.NewDateTimeFromTime(expiresAt),
+		CreatedAt:     revokedBefore,
+	})
+	return err
+}
+
+// RevokeAllUserTokens invalidates every access token issued to the user so far and revokes all
+// of their refresh tokens, which logs the user out on every device.
+func (r *repositoryImpl) RevokeAllUserTokens(c context.Context, userID primitive.ObjectID) error {
+	if err := r.RevokeUserAccessTokens(c, userID); err != nil {
+		return err
+	}
+
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revokedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	if _, err := r.refreshTokens.UpdateMany(c, filter, update); err != nil {
+		return err
+	}
+
+	_, err := r.sessions.UpdateMany(c, filter, update)
+	return err
+}
+
+// IsTokenRevoked reports whether the access token identified by jti, its session, or every token
+// of the user issued at issuedAt, has been revoked. sessionID is NilObjectID for tokens issued
+// before sessions were recorded.
+func (r *repositoryImpl) IsTokenRevoked(c context.Context, jti string, userID primitive.ObjectID, sessionID primitive.ObjectID, issuedAt time.Time) (bool, error) {
+	conditions := []bson.M{
+		{"userId": userID, "revokedBefore": bson.M{"$gt": primitive.NewDateTimeFromTime(issuedAt)}},
+	}
+	if jti != "" {
+		conditions = append(conditions, bson.M{"jti": jti})
+	}
+	if !sessionID.IsZero() {
+		conditions = append(conditions, bson.M{"sessionId": sessionID})
+	}
+
+	count, err := r.revokedTokens.CountDocuments(c, bson.M{"$or": conditions}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CreatePasswordResetToken stores a new reset token and invalidates any token previously sent
+// to the same user, so that only the most recent email works.
+func (r *repositoryImpl) CreatePasswordResetToken(c context.Context, token PasswordResetToken) error {
+	filter := bson.M{"userId": token.UserID, "usedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"usedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	if _, err := r.passwordResetTokens.UpdateMany(c, filter, update); err != nil {
+		return err
+	}
+
+	_, err := r.passwordResetTokens.InsertOne(c, token)
+	return err
+}
+
+// GetPasswordResetToken returns an unused and unexpired reset token without consuming it.
+func (r *repositoryImpl) GetPasswordResetToken(c context.Context, tokenHash string) (*PasswordResetToken, error) {
+	filter := bson.M{
+		"tokenHash": tokenHash,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+
+	var token PasswordResetToken
+	err := r.passwordResetTokens.FindOne(c, filter).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ConsumePasswordResetToken marks an unused, unexpired reset token as used and returns it.
+// It returns mongo.ErrNoDocuments when no such token exists.
+func (r *repositoryImpl) ConsumePasswordResetToken(c context.Context, tokenHash string) (*PasswordResetToken, error) {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{
+		"tokenHash": tokenHash,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"usedAt": now}}
+
+	var token PasswordResetToken
+	err := r.passwordResetTokens.FindOneAndUpdate(c, filter, update).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *repositoryImpl) CreateOIDCState(c context.Context, state OIDCState) error {
+	_, err := r.oidcStates.InsertOne(c, state)
+	return err
+}
+
+// ConsumeOIDCState removes and returns an unexpired login state. It returns
+// mongo.ErrNoDocuments when the state is unknown, expired or already used.
+func (r *repositoryImpl) ConsumeOIDCState(c context.Context, stateHash string) (*OIDCState, error) {
+	filter := bson.M{
+		"stateHash": stateHash,
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+
+	var state OIDCState
+	err := r.oidcStates.FindOneAndDelete(c, filter).Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *repositoryImpl) CreatePersonalAccessToken(c context.Context, token PersonalAccessToken) error {
+	_, err := r.accessTokens.InsertOne(c, token)
+	return err
+}
+
+func (r *repositoryImpl) GetPersonalAccessTokens(c context.Context, userID primitive.ObjectID) ([]PersonalAccessToken, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.accessTokens.Find(c, bson.M{"userId": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	tokens := []PersonalAccessToken{}
+	if err := cursor.All(c, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *repositoryImpl) GetPersonalAccessTokenByHash(c context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	filter := bson.M{"tokenHash": tokenHash}
+
+	var token PersonalAccessToken
+	err := r.accessTokens.FindOne(c, filter).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// TouchPersonalAccessToken records that a token was used. Writes are limited to one a minute
+// per token so that busy integrations do not cause a write on every request.
+func (r *repositoryImpl) TouchPersonalAccessToken(c context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"lastUsedAt": bson.M{"$exists": false}},
+			{"lastUsedAt": bson.M{"$lt": primitive.NewDateTimeFromTime(now.Add(-time.Minute))}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"lastUsedAt": primitive.NewDateTimeFromTime(now)}}
+
+	_, err := r.accessTokens.UpdateOne(c, filter, update)
+	return err
+}
+
+// RevokePersonalAccessToken revokes one of the user's tokens. It returns mongo.ErrNoDocuments
+// when the user has no such active token.
+func (r *repositoryImpl) RevokePersonalAccessToken(c context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "userId": userID, "revokedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revokedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	result, err := r.accessTokens.UpdateOne(c, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetPrincipal(c context.Context, userID primitive.ObjectID) (*Principal, error) {
+	projection := bson.M{"organisationId": 1, "email": 1, "emailVerified": 1, "roles": 1, "deactivatedAt": 1}
+
+	var principal Principal
+	err := r.users.FindOne(c, bson.M{"_id": userID}, options.FindOne().SetProjection(projection)).Decode(&principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(principal.Roles) == 0 {
+		principal.Roles = []string{RoleEmployee}
+	}
+
+	return &principal, nil
+}
+
+func (r *repositoryImpl) GetLoginAttempts(c context.Context, keys []string) ([]LoginAttempt, error) {
+	cursor, err := r.loginAttempts.Find(c, bson.M{"key": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	attempts := []LoginAttempt{}
+	if err := cursor.All(c, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// IncrementLoginFailures atomically counts a failed login for key and returns the updated count.
+// The counter is removed by the TTL index once expiresAt has passed.
+func (r *repositoryImpl) IncrementLoginFailures(c context.Context, key string, expiresAt time.Time) (*LoginAttempt, error) {
+	filter := bson.M{"key": key}
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{
+			"lastFailureAt": primitive.NewDateTimeFromTime(time.Now()),
+			"expiresAt":     primitive.NewDateTimeFromTime(expiresAt),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempt LoginAttempt
+	err := r.loginAttempts.FindOneAndUpdate(c, filter, update, opts).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// LockLogin blocks logins for key until lockedUntil. The counter is kept at least as long as
+// the lockout so that the next failure backs off further.
+func (r *repositoryImpl) LockLogin(c context.Context, key string, lockedUntil time.Time) error {
+	filter := bson.M{"key": key}
+	update := bson.M{
+		"$set": bson.M{"lockedUntil": primitive.NewDateTimeFromTime(lockedUntil)},
+		"$max": bson.M{"expiresAt": primitive.NewDateTimeFromTime(lockedUntil)},
+	}
+
+	_, err := r.loginAttempts.UpdateOne(c, filter, update)
+	return err
+}
+
+func (r *repositoryImpl) ClearLoginAttempts(c context.Context, key string) error {
+	_, err := r.loginAttempts.DeleteOne(c, bson.M{"key": key})
+	return err
+}
+
+func (r *repositoryImpl) CreateSession(c context.Context, session Session) error {
+	_, err := r.sessions.InsertOne(c, session)
+	return err
+}
+
+// GetSessions returns the active sessions of a user, most recently used first.
+func (r *repositoryImpl) GetSessions(c context.Context, userID primitive.ObjectID) ([]Session, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}})
+
+	cursor, err := r.sessions.Find(c, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	sessions := []Session{}
+	if err := cursor.All(c, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RefreshSession records that a session exchanged its refresh token and extends its expiry.
+// Sessions of logins made before sessions were recorded are created on the way.
+func (r *repositoryImpl) RefreshSession(c context.Context, session Session) error {
+	filter := bson.M{"_id": session.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"ip":         session.IP,
+			"lastSeenAt": session.LastSeenAt,
+			"expiresAt":  session.ExpiresAt,
+		},
+		"$setOnInsert": bson.M{
+			"organisationId": session.OrganisationID,
+			"userId":         session.UserID,
+			"userAgent":      session.UserAgent,
+			"createdAt":      session.CreatedAt,
+		},
+	}
+
+	_, err := r.sessions.UpdateOne(c, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// TouchSession records that a session was used. Like TouchPersonalAccessToken, writes are
+// limited to one a minute per session.
+func (r *repositoryImpl) TouchSession(c context.Context, id primitive.ObjectID, ip string) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":        id,
+		"lastSeenAt": bson.M{"$lt": primitive.NewDateTimeFromTime(now.Add(-time.Minute))},
+	}
+	update := bson.M{"$set": bson.M{
+		"ip":         ip,
+		"lastSeenAt": primitive.NewDateTimeFromTime(now),
+	}}
+
+	_, err := r.sessions.UpdateOne(c, filter, update)
+	return err
+}
+
+// RevokeSession logs one of the user's sessions out. It returns mongo.ErrNoDocuments when the
+// user has no such active session.
+func (r *repositoryImpl) RevokeSession(c context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "userId": userID, "revokedAt": bson.M{"$exists": false}}
+
+	count, err := r.sessions.CountDocuments(c, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return r.RevokeRefreshTokenFamily(c, id)
+}
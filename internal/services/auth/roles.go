@@ -0,0 +1,71 @@
+package auth
+
+// Roles a user can hold. Users without any stored role are employees.
+const (
+	RoleAdmin    = "admin"
+	RoleHR       = "hr"
+	RoleManager  = "manager"
+	RoleEmployee = "employee"
+)
+
+// Permissions granted through roles.
+const (
+	PermissionListUsers   = "users:list"
+	PermissionManageRoles = "roles:manage"
+	PermissionUnlockUsers = "users:unlock"
+	PermissionImpersonate = "users:impersonate"
+	PermissionReadAudit   = "audit:read"
+	PermissionInviteUsers = "users:invite"
+	PermissionSyncUsers   = "users:sync"
+	PermissionManageOrg   = "organisation:manage"
+
+	PermissionManageReportingLines = "reporting-lines:manage"
+	PermissionEditUsers            = "users:edit"
+)
+
+var rolePermissions = map[string][]string{
+	RoleAdmin:    {PermissionListUsers, PermissionManageRoles, PermissionUnlockUsers, PermissionImpersonate, PermissionReadAudit, PermissionInviteUsers, PermissionSyncUsers, PermissionManageOrg, PermissionManageReportingLines, PermissionEditUsers},
+	RoleHR:       {PermissionListUsers, PermissionUnlockUsers, PermissionInviteUsers, PermissionManageReportingLines, PermissionEditUsers},
+	RoleManager:  {PermissionInviteUsers},
+	RoleEmployee: {},
+}
+
+// Roles lists every known role.
+func Roles() []string {
+	return []string{RoleAdmin, RoleHR, RoleManager, RoleEmployee}
+}
+
+// PermissionsForRole returns the permissions granted by a role.
+func PermissionsForRole(role string) []string {
+	return rolePermissions[role]
+}
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasRole reports whether roles contains any of the wanted roles.
+func HasRole(roles []string, wanted ...string) bool {
+	for _, role := range roles {
+		for _, w := range wanted {
+			if role == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether any of the roles grants the permission.
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
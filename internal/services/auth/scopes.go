@@ -0,0 +1,34 @@
+package auth
+
+import "strings"
+
+// Scopes that can be granted to personal access tokens.
+const (
+	ScopeReportsRead  = "reports:read"
+	ScopeReportsWrite = "reports:write"
+)
+
+// PersonalAccessTokenPrefix makes personal access tokens recognisable, both for the auth
+// middleware and for secret scanners.
+const PersonalAccessTokenPrefix = "oto_"
+
+// Scopes lists every scope a personal access token can be granted.
+func Scopes() []string {
+	return []string{ScopeReportsRead, ScopeReportsWrite}
+}
+
+// HasScope reports whether scopes contains scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access token rather
+// than a JWT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
@@ -0,0 +1,88 @@
+package directory
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DirectoryHandler struct {
+	Syncer *Syncer
+}
+
+func NewDirectoryHandler(syncer *Syncer) *DirectoryHandler {
+	return &DirectoryHandler{Syncer: syncer}
+}
+
+// @Summary Run the scheduled directory sync
+// @Description Reconcile the users of the LDAP directory into the user collection. Called by the Vercel cron with CRON_SECRET as bearer token. Only reports the changes when LDAP_SYNC_DRY_RUN is set. Syncs that find no users, or would deactivate more than LDAP_MAX_DEACTIVATIONS, are refused.
+// @Tags directory
+// @Produce json
+// @Success 200 {object} SyncReport "Directory synced successfully"
+// @Failure 401 {object} map[string]interface{} "Invalid cron secret"
+// @Failure 404 {object} map[string]interface{} "Directory sync is not configured"
+// @Failure 502 {object} map[string]interface{} "Directory unavailable"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /directory/sync [get]
+func (h *DirectoryHandler) RunScheduledSync(c *gin.Context) {
+	h.sync(c, config.AppConfig().LDAP.SyncDryRun)
+}
+
+// @Summary Sync the directory
+// @Description Reconcile the users of the LDAP directory into the user collection: create, update, deactivate and reactivate users and rebuild their reporting lines. A dry run only reports the changes. Syncs that find no users, or would deactivate more than LDAP_MAX_DEACTIVATIONS, are refused.
+// @Tags directory
+// @Produce json
+// @Param dryRun query bool false "Only report the changes"
+// @Success 200 {object} SyncReport "Directory synced successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Directory sync is not configured"
+// @Failure 502 {object} map[string]interface{} "Directory unavailable"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /directory/sync [post]
+func (h *DirectoryHandler) Sync(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "dryRun must be true or false", nil)
+		return
+	}
+
+	h.sync(c, dryRun)
+}
+
+func (h *DirectoryHandler) sync(c *gin.Context, dryRun bool) {
+	if config.AppConfig().LDAP.URL == "" {
+		api.Error(c, http.StatusNotFound, "Directory sync is not configured", nil)
+		return
+	}
+
+	report, err := h.Syncer.Sync(c.Request.Context(), dryRun)
+	if err != nil {
+		log.Println("Directory sync failed: ", err)
+		if errors.Is(err, ErrUnavailable) {
+			api.Error(c, http.StatusBadGateway, "Directory unavailable", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	log.Printf("Directory sync (dry run: %t): %d created, %d updated, %d deactivated, %d reactivated, %d manager changes, %d warnings",
+		dryRun, len(report.Created), len(report.Updated), len(report.Deactivated), len(report.Reactivated),
+		len(report.ManagerChanges), len(report.Warnings))
+
+	message := "Synced directory successfully"
+	if report.Refused != "" {
+		log.Println("Directory sync refused: ", report.Refused)
+		message = "Directory sync refused, nothing was changed"
+	}
+
+	api.Success(c, http.StatusOK, message, report)
+}
@@ -0,0 +1,117 @@
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"one-to-one/internal/config"
+)
+
+var (
+	ErrNotConfigured = errors.New("directory sync is not configured")
+	ErrUnavailable   = errors.New("directory unavailable")
+)
+
+// Directory is the source of truth the sync reads users from.
+type Directory interface {
+	SearchUsers(c context.Context) ([]Entry, error)
+}
+
+// ldapPageSize is below the default size limit of Active Directory.
+const ldapPageSize = 500
+
+// uacAccountDisable is the ACCOUNTDISABLE flag of the Active Directory userAccountControl attribute.
+const uacAccountDisable = 0x2
+
+type ldapDirectory struct{}
+
+// NewLDAPDirectory returns the directory configured with the LDAP_ settings.
+func NewLDAPDirectory() Directory {
+	return &ldapDirectory{}
+}
+
+// SearchUsers reads every entry matching LDAP_USER_FILTER under LDAP_BASE_DN.
+func (d *ldapDirectory) SearchUsers(c context.Context) ([]Entry, error) {
+	ldapConfig := config.AppConfig().LDAP
+	if ldapConfig.URL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	conn, err := ldap.DialURL(ldapConfig.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := c.Deadline(); ok {
+		conn.SetTimeout(time.Until(deadline))
+	}
+
+	if ldapConfig.StartTLS {
+		serverURL, err := url.Parse(ldapConfig.URL)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: serverURL.Hostname()}); err != nil {
+			return nil, err
+		}
+	}
+
+	if ldapConfig.BindDN != "" {
+		if err := conn.Bind(ldapConfig.BindDN, ldapConfig.BindPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	request := ldap.NewSearchRequest(
+		ldapConfig.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		ldapConfig.UserFilter,
+		[]string{"mail", "givenName", "sn", "manager", "userAccountControl"},
+		nil,
+	)
+	result, err := conn.SearchWithPaging(request, ldapPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(result.Entries))
+	for _, ldapEntry := range result.Entries {
+		uac, _ := strconv.Atoi(ldapEntry.GetAttributeValue("userAccountControl"))
+		entries = append(entries, Entry{
+			DN:        ldapEntry.DN,
+			Email:     ldapEntry.GetAttributeValue("mail"),
+			FirstName: ldapEntry.GetAttributeValue("givenName"),
+			LastName:  ldapEntry.GetAttributeValue("sn"),
+			ManagerDN: ldapEntry.GetAttributeValue("manager"),
+			Disabled:  uac&uacAccountDisable != 0,
+		})
+	}
+
+	return entries, nil
+}
+
+// normalizeDN returns a form of dn that compares equal for DNs that only differ in case or in
+// spacing, as the manager attribute need not be written the way the entry's DN is.
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+
+	rdns := make([]string, len(parsed.RDNs))
+	for i, rdn := range parsed.RDNs {
+		attributes := make([]string, len(rdn.Attributes))
+		for j, attribute := range rdn.Attributes {
+			attributes[j] = strings.ToLower(attribute.Type) + "=" + strings.ToLower(attribute.Value)
+		}
+		rdns[i] = strings.Join(attributes, "+")
+	}
+
+	return strings.Join(rdns, ",")
+}
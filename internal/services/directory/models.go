@@ -0,0 +1,43 @@
+package directory
+
+// Entry is a user as read from the directory. ManagerDN is the DN of the entry of their manager,
+// empty when they have none.
+type Entry struct {
+	DN        string
+	Email     string
+	FirstName string
+	LastName  string
+	ManagerDN string
+	Disabled  bool
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// SyncReport lists the changes a sync made, or would make on a dry run. When the sync looks
+// unsafe, Refused says why and nothing is changed.
+type SyncReport struct {
+	DryRun         bool            `json:"dryRun"`
+	Created        []UserChange    `json:"created"`
+	Updated        []UserChange    `json:"updated"`
+	Deactivated    []UserChange    `json:"deactivated"`
+	Reactivated    []UserChange    `json:"reactivated"`
+	ManagerChanges []ManagerChange `json:"managerChanges"`
+	Warnings       []string        `json:"warnings"`          // entries that were skipped and why
+	Refused        string          `json:"refused,omitempty"` // why none of the changes were applied
+}
+
+type UserChange struct {
+	Email  string   `json:"email"`
+	DN     string   `json:"dn,omitempty"`
+	Fields []string `json:"fields,omitempty"` // attributes that changed, for updated users
+}
+
+// ManagerChange is a new reporting line. From and To are the emails of the previous and the new
+// manager, nil for no manager.
+type ManagerChange struct {
+	Email string  `json:"email"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
@@ -0,0 +1,313 @@
+package directory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/user"
+)
+
+// Syncer reconciles the users of the directory into the User collection. Accounts it creates are
+// tied to their entry through DirectoryDN, existing accounts are adopted by email address. Only
+// accounts tied to an entry are deactivated when the entry disappears, so local accounts such as
+// the first admin are left alone.
+type Syncer struct {
+	Directory Directory
+	UserRepo  user.UserRepository
+	AuthRepo  auth.AuthRepository
+}
+
+func NewSyncer(directory Directory, userRepo user.UserRepository, authRepo auth.AuthRepository) *Syncer {
+	return &Syncer{Directory: directory, UserRepo: userRepo, AuthRepo: authRepo}
+}
+
+// syncPlan holds the changes that bring the User collection in line with the directory.
+type syncPlan struct {
+	creates     []user.User
+	identities  []identityUpdate
+	activations []activation
+	managers    []managerAssignment
+	report      SyncReport
+}
+
+type identityUpdate struct {
+	before user.User
+	after  user.User
+}
+
+type activation struct {
+	account user.User
+	active  bool
+}
+
+type managerAssignment struct {
+	account   user.User
+	managerID *primitive.ObjectID
+}
+
+// Sync reads the directory and applies the differences, or only reports them when dryRun is
+// set. A sync that fails halfway leaves the changes made so far, the next one picks up the rest.
+func (s *Syncer) Sync(c context.Context, dryRun bool) (SyncReport, error) {
+	entries, err := s.Directory.SearchUsers(c)
+	if err != nil {
+		return SyncReport{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	users, err := s.UserRepo.GetAllUsers(c)
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	plan := planSync(entries, users, config.AppConfig().LDAP.MaxDeactivations)
+	plan.report.DryRun = dryRun
+	if dryRun || plan.report.Refused != "" {
+		return plan.report, nil
+	}
+
+	err = s.apply(c, &plan)
+	return plan.report, err
+}
+
+func (s *Syncer) apply(c context.Context, plan *syncPlan) error {
+	for _, account := range plan.creates {
+		if _, err := s.UserRepo.CreateUser(c, account); err != nil {
+			return err
+		}
+	}
+
+	for _, update := range plan.identities {
+		before, after := update.before, update.after
+		if after.Email != before.Email || after.FirstName != before.FirstName || after.LastName != before.LastName {
+			err := s.UserRepo.UpdateIdentity(c, after.ID, after.Email, after.FirstName, after.LastName, after.ExternalID)
+			if err != nil {
+				return err
+			}
+		}
+		if after.DirectoryDN != before.DirectoryDN {
+			if err := s.UserRepo.SetDirectoryDN(c, after.ID, after.DirectoryDN); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, change := range plan.activations {
+		if err := s.UserRepo.SetActive(c, change.account.ID, change.active); err != nil {
+			return err
+		}
+		if !change.active {
+			if err := s.AuthRepo.RevokeAllUserTokens(c, change.account.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, assignment := range plan.managers {
+		// A directory that makes a user their own manager, or a cycle, is reported instead of
+		// stopping the sync.
+		if err := s.UserRepo.SetManager(c, assignment.account.ID, assignment.managerID); err != nil {
+			if !user.IsReportingLineError(err) {
+				return err
+			}
+			plan.report.Warnings = append(plan.report.Warnings, fmt.Sprintf("Manager of %s not changed: %v", assignment.account.Email, err))
+		}
+	}
+
+	return nil
+}
+
+// planSync compares the directory entries with the stored users. It makes no changes, so that a
+// dry run reports exactly what a real sync would do. A directory that returns no entries, or
+// that would deactivate more than maxDeactivations users, more likely has a wrong base DN or
+// filter than lost its users, so the plan is refused rather than applied.
+func planSync(entries []Entry, users []user.User, maxDeactivations int) syncPlan {
+	plan := syncPlan{report: SyncReport{
+		Created:        []UserChange{},
+		Updated:        []UserChange{},
+		Deactivated:    []UserChange{},
+		Reactivated:    []UserChange{},
+		ManagerChanges: []ManagerChange{},
+		Warnings:       []string{},
+	}}
+	warn := func(format string, args ...interface{}) {
+		plan.report.Warnings = append(plan.report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	byDN := map[string]*user.User{}
+	byEmail := map[string]*user.User{}
+	emails := map[primitive.ObjectID]string{}
+	for i := range users {
+		account := &users[i]
+		if account.DirectoryDN != "" {
+			byDN[normalizeDN(account.DirectoryDN)] = account
+		}
+		byEmail[strings.ToLower(account.Email)] = account
+		emails[account.ID] = account.Email
+	}
+
+	// accounts maps the normalized DN of every synced entry to its user, as it will be stored.
+	accounts := map[string]user.User{}
+	matched := map[primitive.ObjectID]bool{}
+	entryEmails := map[string]bool{}
+
+	for _, entry := range entries {
+		if entry.Email == "" {
+			warn("%s has no email address", entry.DN)
+			continue
+		}
+		dn, emailKey := normalizeDN(entry.DN), strings.ToLower(entry.Email)
+		if entryEmails[emailKey] {
+			warn("%s has the email address %s of another entry", entry.DN, entry.Email)
+			continue
+		}
+		entryEmails[emailKey] = true
+
+		account, found := byDN[dn]
+		if !found {
+			account, found = byEmail[emailKey]
+		}
+		if found && matched[account.ID] {
+			warn("%s matches %s, which is already synced from another entry", entry.DN, account.Email)
+			continue
+		}
+
+		if !found {
+			if entry.Disabled {
+				continue
+			}
+
+			newUser := newDirectoryUser(entry)
+			plan.creates = append(plan.creates, newUser)
+			plan.report.Created = append(plan.report.Created, UserChange{Email: newUser.Email, DN: entry.DN})
+
+			accounts[dn] = newUser
+			emails[newUser.ID] = newUser.Email
+			continue
+		}
+
+		matched[account.ID] = true
+		updated := *account
+		fields := []string{}
+
+		if !strings.EqualFold(entry.Email, account.Email) {
+			if other, taken := byEmail[emailKey]; taken && other.ID != account.ID {
+				warn("%s cannot be renamed to %s, another user has this email address", account.Email, entry.Email)
+			} else {
+				updated.Email = entry.Email
+				fields = append(fields, "email")
+			}
+		}
+		if entry.FirstName != account.FirstName {
+			updated.FirstName = entry.FirstName
+			fields = append(fields, "firstName")
+		}
+		if entry.LastName != account.LastName {
+			updated.LastName = entry.LastName
+			fields = append(fields, "lastName")
+		}
+		if account.DirectoryDN == "" || normalizeDN(account.DirectoryDN) != dn {
+			updated.DirectoryDN = entry.DN
+			fields = append(fields, "dn")
+		}
+
+		if len(fields) > 0 {
+			plan.identities = append(plan.identities, identityUpdate{before: *account, after: updated})
+			plan.report.Updated = append(plan.report.Updated, UserChange{Email: updated.Email, DN: entry.DN, Fields: fields})
+		}
+
+		if account.Active() == entry.Disabled {
+			plan.activations = append(plan.activations, activation{account: updated, active: !entry.Disabled})
+			change := UserChange{Email: updated.Email, DN: entry.DN}
+			if entry.Disabled {
+				plan.report.Deactivated = append(plan.report.Deactivated, change)
+			} else {
+				plan.report.Reactivated = append(plan.report.Reactivated, change)
+			}
+		}
+
+		accounts[dn] = updated
+		emails[updated.ID] = updated.Email
+	}
+
+	for _, account := range users {
+		if account.DirectoryDN == "" || matched[account.ID] || !account.Active() {
+			continue
+		}
+		plan.activations = append(plan.activations, activation{account: account, active: false})
+		plan.report.Deactivated = append(plan.report.Deactivated, UserChange{Email: account.Email, DN: account.DirectoryDN})
+	}
+
+	if len(entries) == 0 {
+		plan.report.Refused = "the directory returned no users"
+	} else if maxDeactivations > 0 && len(plan.report.Deactivated) > maxDeactivations {
+		plan.report.Refused = fmt.Sprintf("%d users would be deactivated, more than LDAP_MAX_DEACTIVATIONS (%d)", len(plan.report.Deactivated), maxDeactivations)
+	}
+
+	// Reporting lines are resolved once every entry is matched, as managers may come after their
+	// reportees.
+	emailOf := func(id *primitive.ObjectID) *string {
+		if id == nil {
+			return nil
+		}
+		email := emails[*id]
+		return &email
+	}
+	for _, entry := range entries {
+		account, ok := accounts[normalizeDN(entry.DN)]
+		if !ok {
+			continue
+		}
+
+		var managerID *primitive.ObjectID
+		if entry.ManagerDN != "" {
+			manager, ok := accounts[normalizeDN(entry.ManagerDN)]
+			if !ok {
+				warn("the manager %s of %s is not a synced user", entry.ManagerDN, account.Email)
+				continue
+			}
+			if manager.ID == account.ID {
+				warn("%s is their own manager", account.Email)
+				continue
+			}
+			managerID = &manager.ID
+		}
+
+		if sameManager(account.ReportsTo, managerID) {
+			continue
+		}
+		plan.managers = append(plan.managers, managerAssignment{account: account, managerID: managerID})
+		plan.report.ManagerChanges = append(plan.report.ManagerChanges, ManagerChange{
+			Email: account.Email,
+			From:  emailOf(account.ReportsTo),
+			To:    emailOf(managerID),
+		})
+	}
+
+	return plan
+}
+
+// newDirectoryUser builds the account for a directory entry. The directory vouches for the email
+// address, and the reporting line is set by the sync.
+func newDirectoryUser(entry Entry) user.User {
+	account := user.NewProvisionedUser(entry.Email, entry.FirstName, entry.LastName)
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	account.DirectoryDN = entry.DN
+	account.EmailVerified = true
+	account.EmailVerifiedAt = &now
+	account.CreatedAt = now
+	account.UpdatedAt = now
+
+	return account
+}
+
+func sameManager(current *primitive.ObjectID, wanted *primitive.ObjectID) bool {
+	if current == nil || wanted == nil {
+		return current == wanted
+	}
+	return *current == *wanted
+}
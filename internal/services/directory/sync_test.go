@@ -0,0 +1,332 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/user"
+)
+
+// stubDirectory returns a fixed set of entries, or fails like an unreachable server.
+type stubDirectory struct {
+	entries []Entry
+	err     error
+}
+
+func (d *stubDirectory) SearchUsers(c context.Context) ([]Entry, error) {
+	return d.entries, d.err
+}
+
+// fakeUserRepo keeps users in memory and records the writes of a sync. Methods the sync does not
+// use are left to the embedded nil interface.
+type fakeUserRepo struct {
+	user.UserRepository
+	users       []user.User
+	created     []user.User
+	identities  []string
+	activations map[primitive.ObjectID]bool
+	managers    map[primitive.ObjectID]*primitive.ObjectID
+}
+
+func newFakeUserRepo(users ...user.User) *fakeUserRepo {
+	return &fakeUserRepo{
+		users:       users,
+		activations: map[primitive.ObjectID]bool{},
+		managers:    map[primitive.ObjectID]*primitive.ObjectID{},
+	}
+}
+
+func (r *fakeUserRepo) GetAllUsers(c context.Context) ([]user.User, error) {
+	return append([]user.User{}, r.users...), nil
+}
+
+func (r *fakeUserRepo) CreateUser(c context.Context, account user.User) (user.User, error) {
+	r.created = append(r.created, account)
+	return account, nil
+}
+
+func (r *fakeUserRepo) UpdateIdentity(c context.Context, userID primitive.ObjectID, email string, firstName string, lastName string, externalID string) error {
+	r.identities = append(r.identities, email)
+	return nil
+}
+
+func (r *fakeUserRepo) SetDirectoryDN(c context.Context, userID primitive.ObjectID, dn string) error {
+	return nil
+}
+
+func (r *fakeUserRepo) SetActive(c context.Context, userID primitive.ObjectID, active bool) error {
+	r.activations[userID] = active
+	return nil
+}
+
+func (r *fakeUserRepo) SetManager(c context.Context, userID primitive.ObjectID, managerID *primitive.ObjectID) error {
+	if managerID != nil && *managerID == userID {
+		return user.ErrReportingCycle
+	}
+	r.managers[userID] = managerID
+	return nil
+}
+
+type fakeAuthRepo struct {
+	auth.AuthRepository
+	revoked []primitive.ObjectID
+}
+
+func (r *fakeAuthRepo) RevokeAllUserTokens(c context.Context, userID primitive.ObjectID) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+func directoryUser(email string, dn string) user.User {
+	account := user.NewProvisionedUser(email, "First", "Last")
+	account.DirectoryDN = dn
+	return account
+}
+
+func deactivated(account user.User) user.User {
+	now := primitive.NewDateTimeFromTime(account.ID.Timestamp())
+	account.DeactivatedAt = &now
+	return account
+}
+
+func emailsOf(changes []UserChange) []string {
+	emails := make([]string, len(changes))
+	for i, change := range changes {
+		emails[i] = change.Email
+	}
+	return emails
+}
+
+func TestPlanSyncCreatesAndAdoptsUsers(t *testing.T) {
+	local := user.NewProvisionedUser("admin@example.com", "Ada", "Admin")
+	adopted := user.NewProvisionedUser("grace@example.com", "Grace", "Hopper")
+
+	entries := []Entry{
+		{DN: "CN=Grace,OU=People,DC=example,DC=com", Email: "Grace@Example.com", FirstName: "Grace", LastName: "Brewster Hopper"},
+		{DN: "cn=alan,ou=people,dc=example,dc=com", Email: "alan@example.com", FirstName: "Alan", LastName: "Turing"},
+		{DN: "cn=gone,ou=people,dc=example,dc=com", Email: "gone@example.com", Disabled: true},
+		{DN: "cn=nomail,ou=people,dc=example,dc=com"},
+	}
+
+	plan := planSync(entries, []user.User{local, adopted}, 0)
+
+	if got := emailsOf(plan.report.Created); len(got) != 1 || got[0] != "alan@example.com" {
+		t.Errorf("created %v, want only alan@example.com", got)
+	}
+	if len(plan.creates) != 1 || plan.creates[0].DirectoryDN != entries[1].DN || !plan.creates[0].EmailVerified {
+		t.Errorf("new user not tied to its entry: %+v", plan.creates)
+	}
+
+	if len(plan.report.Updated) != 1 {
+		t.Fatalf("updated %+v, want the adopted user only", plan.report.Updated)
+	}
+	if fields := strings.Join(plan.report.Updated[0].Fields, ","); fields != "lastName,dn" {
+		t.Errorf("updated fields %q, want lastName,dn", fields)
+	}
+	if len(plan.report.Deactivated) != 0 {
+		t.Errorf("deactivated %v, local accounts must be left alone", emailsOf(plan.report.Deactivated))
+	}
+	if len(plan.report.Warnings) != 1 || !strings.Contains(plan.report.Warnings[0], "no email address") {
+		t.Errorf("warnings %v, want one about the entry without email", plan.report.Warnings)
+	}
+}
+
+func TestPlanSyncActivations(t *testing.T) {
+	staying := directoryUser("stay@example.com", "cn=stay,dc=example,dc=com")
+	leaving := directoryUser("leave@example.com", "cn=leave,dc=example,dc=com")
+	disabled := directoryUser("disabled@example.com", "cn=disabled,dc=example,dc=com")
+	returning := deactivated(directoryUser("back@example.com", "cn=back,dc=example,dc=com"))
+	local := user.NewProvisionedUser("admin@example.com", "Ada", "Admin")
+
+	entries := []Entry{
+		{DN: staying.DirectoryDN, Email: staying.Email, FirstName: "First", LastName: "Last"},
+		{DN: disabled.DirectoryDN, Email: disabled.Email, FirstName: "First", LastName: "Last", Disabled: true},
+		{DN: returning.DirectoryDN, Email: returning.Email, FirstName: "First", LastName: "Last"},
+	}
+
+	plan := planSync(entries, []user.User{staying, leaving, disabled, returning, local}, 0)
+
+	deactivatedEmails := strings.Join(emailsOf(plan.report.Deactivated), ",")
+	if deactivatedEmails != "disabled@example.com,leave@example.com" {
+		t.Errorf("deactivated %s", deactivatedEmails)
+	}
+	if got := emailsOf(plan.report.Reactivated); len(got) != 1 || got[0] != "back@example.com" {
+		t.Errorf("reactivated %v", got)
+	}
+	if plan.report.Refused != "" {
+		t.Errorf("refused: %s", plan.report.Refused)
+	}
+}
+
+func TestPlanSyncManagers(t *testing.T) {
+	boss := directoryUser("boss@example.com", "cn=boss,dc=example,dc=com")
+	worker := directoryUser("worker@example.com", "cn=worker,dc=example,dc=com")
+	worker.ReportsTo = &boss.ID
+
+	entries := []Entry{
+		// Reportees may come before their managers, and the manager DN may be spelled differently.
+		{DN: "cn=new,dc=example,dc=com", Email: "new@example.com", ManagerDN: "CN=Boss, DC=example, DC=com"},
+		{DN: worker.DirectoryDN, Email: worker.Email, FirstName: "First", LastName: "Last", ManagerDN: boss.DirectoryDN},
+		{DN: boss.DirectoryDN, Email: boss.Email, FirstName: "First", LastName: "Last", ManagerDN: "cn=unknown,dc=example,dc=com"},
+		{DN: "cn=self,dc=example,dc=com", Email: "self@example.com", ManagerDN: "cn=self,dc=example,dc=com"},
+	}
+
+	plan := planSync(entries, []user.User{boss, worker}, 0)
+
+	if len(plan.report.ManagerChanges) != 1 {
+		t.Fatalf("manager changes %+v, want only the new user's", plan.report.ManagerChanges)
+	}
+	change := plan.report.ManagerChanges[0]
+	if change.Email != "new@example.com" || change.From != nil || change.To == nil || *change.To != "boss@example.com" {
+		t.Errorf("manager change %+v", change)
+	}
+	if len(plan.managers) != 1 || *plan.managers[0].managerID != boss.ID {
+		t.Errorf("manager assignments %+v", plan.managers)
+	}
+
+	warnings := strings.Join(plan.report.Warnings, "\n")
+	if !strings.Contains(warnings, "cn=unknown,dc=example,dc=com of boss@example.com is not a synced user") {
+		t.Errorf("no warning about the unknown manager: %s", warnings)
+	}
+	if !strings.Contains(warnings, "self@example.com is their own manager") {
+		t.Errorf("no warning about the user managing themselves: %s", warnings)
+	}
+}
+
+func TestPlanSyncSkipsDuplicateEmails(t *testing.T) {
+	entries := []Entry{
+		{DN: "cn=one,dc=example,dc=com", Email: "same@example.com"},
+		{DN: "cn=two,dc=example,dc=com", Email: "SAME@example.com"},
+	}
+
+	plan := planSync(entries, nil, 0)
+
+	if len(plan.creates) != 1 {
+		t.Errorf("created %d users, want 1", len(plan.creates))
+	}
+	if len(plan.report.Warnings) != 1 || !strings.Contains(plan.report.Warnings[0], "cn=two") {
+		t.Errorf("warnings %v", plan.report.Warnings)
+	}
+}
+
+func TestPlanSyncRefusesUnsafeResults(t *testing.T) {
+	users := []user.User{
+		directoryUser("a@example.com", "cn=a,dc=example,dc=com"),
+		directoryUser("b@example.com", "cn=b,dc=example,dc=com"),
+		directoryUser("c@example.com", "cn=c,dc=example,dc=com"),
+	}
+	remaining := []Entry{{DN: "cn=a,dc=example,dc=com", Email: "a@example.com", FirstName: "First", LastName: "Last"}}
+
+	tests := []struct {
+		name             string
+		entries          []Entry
+		maxDeactivations int
+		refused          string
+	}{
+		{"empty directory", nil, 0, "no users"},
+		{"empty directory with a limit", []Entry{}, 10, "no users"},
+		{"above the limit", remaining, 1, "2 users would be deactivated"},
+		{"at the limit", remaining, 2, ""},
+		{"no limit", remaining, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planSync(tt.entries, users, tt.maxDeactivations)
+
+			if tt.refused == "" && plan.report.Refused != "" {
+				t.Errorf("refused: %s", plan.report.Refused)
+			}
+			if tt.refused != "" && !strings.Contains(plan.report.Refused, tt.refused) {
+				t.Errorf("refused %q, want it to mention %q", plan.report.Refused, tt.refused)
+			}
+		})
+	}
+}
+
+func TestSyncAppliesPlan(t *testing.T) {
+	config.AppConfig().LDAP.MaxDeactivations = 0
+
+	boss := directoryUser("boss@example.com", "cn=boss,dc=example,dc=com")
+	leaving := directoryUser("leave@example.com", "cn=leave,dc=example,dc=com")
+	directory := &stubDirectory{entries: []Entry{
+		{DN: boss.DirectoryDN, Email: boss.Email, FirstName: "First", LastName: "Last"},
+		{DN: "cn=new,dc=example,dc=com", Email: "new@example.com", FirstName: "New", LastName: "Hire", ManagerDN: boss.DirectoryDN},
+	}}
+
+	t.Run("dry run", func(t *testing.T) {
+		userRepo, authRepo := newFakeUserRepo(boss, leaving), &fakeAuthRepo{}
+		report, err := NewSyncer(directory, userRepo, authRepo).Sync(context.Background(), true)
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+
+		if !report.DryRun || len(report.Created) != 1 || len(report.Deactivated) != 1 || len(report.ManagerChanges) != 1 {
+			t.Errorf("report %+v", report)
+		}
+		if len(userRepo.created) != 0 || len(userRepo.activations) != 0 || len(userRepo.managers) != 0 || len(authRepo.revoked) != 0 {
+			t.Errorf("a dry run changed users")
+		}
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		userRepo, authRepo := newFakeUserRepo(boss, leaving), &fakeAuthRepo{}
+		if _, err := NewSyncer(directory, userRepo, authRepo).Sync(context.Background(), false); err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+
+		if len(userRepo.created) != 1 || userRepo.created[0].Email != "new@example.com" {
+			t.Fatalf("created %+v", userRepo.created)
+		}
+		if active, ok := userRepo.activations[leaving.ID]; !ok || active {
+			t.Errorf("the user who left was not deactivated")
+		}
+		if len(authRepo.revoked) != 1 || authRepo.revoked[0] != leaving.ID {
+			t.Errorf("revoked the tokens of %v, want only the user who left", authRepo.revoked)
+		}
+		if manager := userRepo.managers[userRepo.created[0].ID]; manager == nil || *manager != boss.ID {
+			t.Errorf("the new user does not report to the boss")
+		}
+	})
+}
+
+func TestSyncRefusalChangesNothing(t *testing.T) {
+	config.AppConfig().LDAP.MaxDeactivations = 1
+
+	users := []user.User{
+		directoryUser("a@example.com", "cn=a,dc=example,dc=com"),
+		directoryUser("b@example.com", "cn=b,dc=example,dc=com"),
+	}
+	for name, directory := range map[string]*stubDirectory{
+		"empty":      {},
+		"wrong base": {entries: []Entry{{DN: "cn=new,dc=example,dc=com", Email: "new@example.com"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			userRepo, authRepo := newFakeUserRepo(users...), &fakeAuthRepo{}
+			report, err := NewSyncer(directory, userRepo, authRepo).Sync(context.Background(), false)
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+
+			if report.Refused == "" {
+				t.Errorf("the sync was not refused")
+			}
+			if len(userRepo.created) != 0 || len(userRepo.activations) != 0 || len(authRepo.revoked) != 0 {
+				t.Errorf("a refused sync changed users")
+			}
+		})
+	}
+}
+
+func TestSyncDirectoryUnavailable(t *testing.T) {
+	directory := &stubDirectory{err: errors.New("connection refused")}
+
+	_, err := NewSyncer(directory, newFakeUserRepo(), &fakeAuthRepo{}).Sync(context.Background(), false)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,21 @@
+package invitation
+
+func ConvertInvitationToInvitationResponse(invitation Invitation) InvitationResponse {
+	response := InvitationResponse{
+		ID:        invitation.ID.Hex(),
+		Email:     invitation.Email,
+		FirstName: invitation.FirstName,
+		LastName:  invitation.LastName,
+		InvitedBy: invitation.InvitedBy.Hex(),
+		Status:    invitation.Status(),
+		ExpiresAt: invitation.ExpiresAt.Time(),
+		CreatedAt: invitation.CreatedAt.Time(),
+	}
+
+	if invitation.AcceptedAt != nil {
+		acceptedAt := invitation.AcceptedAt.Time()
+		response.AcceptedAt = &acceptedAt
+	}
+
+	return response
+}
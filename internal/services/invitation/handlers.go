@@ -0,0 +1,312 @@
+package invitation
+
+import (
+	"log"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/mailer"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+	"one-to-one/internal/tenant"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type InvitationHandler struct {
+	Repo     InvitationRepository
+	UserRepo user.UserRepository
+	AuthRepo auth.AuthRepository
+	OrgRepo  organisation.OrganisationRepository
+	Mailer   mailer.Mailer
+}
+
+func NewInvitationHandler(repo InvitationRepository, userRepo user.UserRepository, authRepo auth.AuthRepository, orgRepo organisation.OrganisationRepository, mail mailer.Mailer) *InvitationHandler {
+	return &InvitationHandler{Repo: repo, UserRepo: userRepo, AuthRepo: authRepo, OrgRepo: orgRepo, Mailer: mail}
+}
+
+// @Summary Invite a reportee
+// @Description Email an invitation to join as a reportee of the current user. Inviting the same email again replaces the previous invitation.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param invitation body CreateInvitationRequest true "Email and optional name of the invitee"
+// @Success 201 {object} InvitationResponse "Invitation sent successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 409 {object} map[string]interface{} "A user with this email already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /invitation [post]
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var reqPayload CreateInvitationRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	inviterID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	if _, err := h.UserRepo.GetUserByEmail(c.Request.Context(), reqPayload.Email); err == nil {
+		api.Error(c, http.StatusConflict, "A user with this email already exists", nil)
+		return
+	} else if err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	now := time.Now()
+	invitation := Invitation{
+		ID:        primitive.NewObjectID(),
+		Email:     reqPayload.Email,
+		FirstName: reqPayload.FirstName,
+		LastName:  reqPayload.LastName,
+		InvitedBy: inviterID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: primitive.NewDateTimeFromTime(now.AddDate(0, 0, config.AppConfig().Auth.InvitationExpire)),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+
+	if err := h.Repo.CreateInvitation(c.Request.Context(), invitation); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	organisationID, _ := tenant.OrganisationID(c.Request.Context())
+	link := user.ClientLink("/accept-invitation", token, organisationID)
+	err = h.Mailer.Send(c.Request.Context(), mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited to OneToOne",
+		Body: "Hi " + invitation.FirstName + ",\n\n" +
+			c.GetString("email") + " invited you to join OneToOne as one of their reportees. Open the link below to create your account. The link expires in " + strconv.Itoa(config.AppConfig().Auth.InvitationExpire) + " days.\n\n" +
+			link + "\n",
+	})
+	if err != nil {
+		log.Println("Failed to send invitation email: ", err)
+		api.Error(c, http.StatusInternalServerError, "Failed to send the invitation email", nil)
+		return
+	}
+
+	api.Success(c, http.StatusCreated, "Sent invitation successfully", ConvertInvitationToInvitationResponse(invitation))
+}
+
+// @Summary Get sent invitations
+// @Description List the invitations sent by the current user, most recent first
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Success 200 {array} InvitationResponse "Invitations retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /invitation [get]
+func (h *InvitationHandler) GetInvitations(c *gin.Context) {
+	inviterID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	invitations, err := h.Repo.GetInvitationsByInviter(c.Request.Context(), inviterID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	response := make([]InvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		response[i] = ConvertInvitationToInvitationResponse(invitation)
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved invitations successfully", response)
+}
+
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation sent by the current user
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "Invitation revoked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid invitation ID"
+// @Failure 404 {object} map[string]interface{} "Invitation not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /invitation/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	inviterID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	invitationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid invitation ID", nil)
+		return
+	}
+
+	if err := h.Repo.RevokeInvitation(c.Request.Context(), inviterID, invitationID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "Invitation not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Revoked invitation successfully", nil)
+}
+
+// @Summary Accept an invitation
+// @Description Create an account from an invitation. The account reports to the manager who sent the invitation, its email is verified, and the user is logged in.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param invitation body AcceptInvitationRequest true "Invitation token and account details"
+// @Success 201 {object} user.LoginResponse "Invitation accepted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, invalid or expired invitation, or password rejected by the password policy"
+// @Failure 409 {object} map[string]interface{} "A user with this email already exists, or the inviter can no longer be the new user's manager"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /invitation/accept [post]
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var reqPayload AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	invitation, err := h.Repo.GetPendingInvitationByHash(c.Request.Context(), auth.HashToken(reqPayload.Token))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired invitation", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	manager, err := h.UserRepo.GetUserByID(c.Request.Context(), invitation.InvitedBy)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired invitation", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := user.CheckPasswordPolicy(nil, reqPayload.Password); err != nil {
+		if user.IsPasswordPolicyError(err) {
+			api.Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		log.Println("Failed to check password policy: ", err)
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if _, err := h.UserRepo.GetUserByEmail(c.Request.Context(), invitation.Email); err == nil {
+		api.Error(c, http.StatusConflict, "A user with this email already exists", nil)
+		return
+	} else if err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	account, err := newInvitedUser(*invitation, reqPayload)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	// Claim the invitation before creating the account, so it can only ever create one. It is
+	// reopened if the account cannot be set up, so the invitee can try again.
+	accepted, err := h.Repo.AcceptInvitation(c.Request.Context(), invitation.ID, account.ID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+	if !accepted {
+		api.Error(c, http.StatusBadRequest, "Invalid or expired invitation", nil)
+		return
+	}
+
+	createdUser, err := h.UserRepo.CreateUser(c.Request.Context(), account)
+	if err != nil {
+		h.reopen(c, invitation.ID, err)
+		return
+	}
+
+	if err := h.UserRepo.SetManager(c.Request.Context(), createdUser.ID, &manager.ID); err != nil {
+		if deleteErr := h.UserRepo.DeleteUser(c.Request.Context(), createdUser.ID); deleteErr != nil {
+			api.Error(c, http.StatusInternalServerError, deleteErr.Error(), nil)
+			return
+		}
+		h.reopen(c, invitation.ID, err)
+		return
+	}
+	createdUser.ReportsTo = &manager.ID
+
+	tokens, err := user.IssueTokens(c.Request.Context(), h.AuthRepo, createdUser, user.NewTokenOptions(c, false))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	mfaRequired, err := h.OrgRepo.MFARequired(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	loginRes := user.ConvertToLoginResponse(tokens, createdUser)
+	loginRes.MFAEnrollmentRequired = mfaRequired
+
+	api.Success(c, http.StatusCreated, "Accepted invitation successfully", loginRes)
+}
+
+// reopen makes a claimed invitation pending again after its account could not be set up, and
+// answers with the reason.
+func (h *InvitationHandler) reopen(c *gin.Context, id primitive.ObjectID, err error) {
+	if reopenErr := h.Repo.ReopenInvitation(c.Request.Context(), id); reopenErr != nil {
+		api.Error(c, http.StatusInternalServerError, reopenErr.Error(), nil)
+		return
+	}
+	if user.IsReportingLineError(err) {
+		api.Error(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+}
+
+// newInvitedUser builds the account for an accepted invitation. Following the link proves the
+// invitee owns the email address, so it starts out verified.
+func newInvitedUser(invitation Invitation, req AcceptInvitationRequest) (user.User, error) {
+	hashed, err := user.HashPassword(req.Password)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	account := user.NewProvisionedUser(invitation.Email, req.FirstName, req.LastName)
+	verifiedAt := primitive.NewDateTimeFromTime(time.Now())
+	account.Password = hashed
+	account.EmailVerified = true
+	account.EmailVerifiedAt = &verifiedAt
+
+	return account, nil
+}
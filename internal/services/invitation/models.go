@@ -0,0 +1,80 @@
+package invitation
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation statuses, derived from the timestamps of an invitation.
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+	StatusExpired  = "expired"
+)
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ CREATE OBJECTS -----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+type CreateInvitationRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"firstName" binding:"omitempty,alpha"`
+	LastName  string `json:"lastName" binding:"omitempty,alpha"`
+}
+
+type AcceptInvitationRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	FirstName string `json:"firstName" binding:"required,alpha"`
+	LastName  string `json:"lastName" binding:"required,alpha"`
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+type InvitationResponse struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	FirstName  string     `json:"firstName,omitempty"`
+	LastName   string     `json:"lastName,omitempty"`
+	InvitedBy  string     `json:"invitedBy"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ MONGO OBJECTS ------------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// Invitation lets a manager onboard a reportee. The emailed token is only stored hashed.
+type Invitation struct {
+	ID             primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID  `json:"-" bson:"organisationId,omitempty"`
+	Email          string              `json:"email" bson:"email"`
+	FirstName      string              `json:"firstName,omitempty" bson:"firstName,omitempty"`
+	LastName       string              `json:"lastName,omitempty" bson:"lastName,omitempty"`
+	InvitedBy      primitive.ObjectID  `json:"invitedBy" bson:"invitedBy"`
+	TokenHash      string              `json:"-" bson:"tokenHash"`
+	ExpiresAt      primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	AcceptedAt     *primitive.DateTime `json:"acceptedAt,omitempty" bson:"acceptedAt,omitempty"`
+	AcceptedUserID *primitive.ObjectID `json:"acceptedUserId,omitempty" bson:"acceptedUserId,omitempty"`
+	RevokedAt      *primitive.DateTime `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+	CreatedAt      primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+}
+
+// Status returns the current status of the invitation.
+func (i Invitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return StatusAccepted
+	case i.RevokedAt != nil:
+		return StatusRevoked
+	case i.ExpiresAt.Time().Before(time.Now()):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,165 @@
+package invitation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"one-to-one/internal/db"
+	"one-to-one/internal/tenant"
+)
+
+type InvitationRepository interface {
+	CreateInvitation(c context.Context, invitation Invitation) error
+	GetInvitationsByInviter(c context.Context, inviterID primitive.ObjectID) ([]Invitation, error)
+	GetPendingInvitationByHash(c context.Context, tokenHash string) (*Invitation, error)
+	AcceptInvitation(c context.Context, id primitive.ObjectID, userID primitive.ObjectID) (bool, error)
+	ReopenInvitation(c context.Context, id primitive.ObjectID) error
+	RevokeInvitation(c context.Context, inviterID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+type repositoryImpl struct {
+	collection *mongo.Collection
+}
+
+var indexesOnce sync.Once
+
+func NewInvitationRepository() InvitationRepository {
+	r := &repositoryImpl{
+		collection: db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_INVITATION),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "invitedBy", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "email", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create invitation indexes: ", err)
+		}
+	})
+
+	return r
+}
+
+// CreateInvitation stores a new invitation and revokes the pending invitations previously sent
+// to the same email, so that only the most recent link works. The invitation belongs to the
+// organisation of the context.
+func (r *repositoryImpl) CreateInvitation(c context.Context, invitation Invitation) error {
+	organisationID, err := tenant.Require(c)
+	if err != nil {
+		return err
+	}
+	invitation.OrganisationID = organisationID
+
+	filter := bson.M{
+		"email":      invitation.Email,
+		"acceptedAt": bson.M{"$exists": false},
+		"revokedAt":  bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"revokedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	if _, err := r.collection.UpdateMany(c, tenant.Filter(c, filter), update); err != nil {
+		return err
+	}
+
+	_, err = r.collection.InsertOne(c, invitation)
+	return err
+}
+
+func (r *repositoryImpl) GetInvitationsByInviter(c context.Context, inviterID primitive.ObjectID) ([]Invitation, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(c, tenant.Filter(c, bson.M{"invitedBy": inviterID}), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	invitations := []Invitation{}
+	if err := cursor.All(c, &invitations); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// GetPendingInvitationByHash returns an invitation that can still be accepted. It returns
+// mongo.ErrNoDocuments when the token is unknown, expired, revoked or already used.
+func (r *repositoryImpl) GetPendingInvitationByHash(c context.Context, tokenHash string) (*Invitation, error) {
+	filter := bson.M{
+		"tokenHash":  tokenHash,
+		"acceptedAt": bson.M{"$exists": false},
+		"revokedAt":  bson.M{"$exists": false},
+		"expiresAt":  bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+
+	var invitation Invitation
+	err := r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// AcceptInvitation marks a pending invitation as accepted by the user created for it. It
+// reports false when the invitation was accepted or revoked in the meantime.
+func (r *repositoryImpl) AcceptInvitation(c context.Context, id primitive.ObjectID, userID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"acceptedAt": bson.M{"$exists": false},
+		"revokedAt":  bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"acceptedAt":     primitive.NewDateTimeFromTime(time.Now()),
+		"acceptedUserId": userID,
+	}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ReopenInvitation makes an accepted invitation pending again, for when the account it was
+// accepted for could not be set up.
+func (r *repositoryImpl) ReopenInvitation(c context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{"acceptedAt": "", "acceptedUserId": ""}}
+
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, bson.M{"_id": id}), update)
+	return err
+}
+
+// RevokeInvitation withdraws a pending invitation sent by the inviter. It returns
+// mongo.ErrNoDocuments when the inviter has no such pending invitation.
+func (r *repositoryImpl) RevokeInvitation(c context.Context, inviterID primitive.ObjectID, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":        id,
+		"invitedBy":  inviterID,
+		"acceptedAt": bson.M{"$exists": false},
+		"revokedAt":  bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"revokedAt": primitive.NewDateTimeFromTime(time.Now())}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
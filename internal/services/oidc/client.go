@@ -0,0 +1,302 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Provider holds the endpoints advertised by the identity provider's discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to a single OpenID Connect identity provider. Discovery and signing keys are
+// fetched lazily and cached, so constructing a client never touches the network.
+type Client struct {
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	provider  *Provider
+	keys      map[string]interface{}
+	keysUntil time.Time
+}
+
+func NewClient(issuerURL string, clientID string, clientSecret string, redirectURL string, scopes []string) *Client {
+	return &Client{
+		issuerURL:    strings.TrimSuffix(issuerURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Configured reports whether enough settings are present to run the login flow.
+func (cl *Client) Configured() bool {
+	return cl.issuerURL != "" && cl.clientID != "" && cl.redirectURL != ""
+}
+
+// Discover fetches and caches the provider's discovery document.
+func (cl *Client) Discover(c context.Context) (*Provider, error) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.provider != nil {
+		return cl.provider, nil
+	}
+
+	var provider Provider
+	if err := cl.getJSON(c, cl.issuerURL+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	if strings.TrimSuffix(provider.Issuer, "/") != cl.issuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", provider.Issuer, cl.issuerURL)
+	}
+
+	cl.provider = &provider
+	return cl.provider, nil
+}
+
+// AuthCodeURL builds the authorization request URL using PKCE with the S256 method.
+func (cl *Client) AuthCodeURL(c context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	provider, err := cl.Discover(c)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", cl.clientID)
+	params.Set("redirect_uri", cl.redirectURL)
+	params.Set("scope", strings.Join(cl.scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return provider.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the raw ID token.
+func (cl *Client) Exchange(c context.Context, code string, codeVerifier string) (string, error) {
+	provider, err := cl.Discover(c)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cl.redirectURL)
+	form.Set("client_id", cl.clientID)
+	form.Set("code_verifier", codeVerifier)
+	if cl.clientSecret != "" {
+		form.Set("client_secret", cl.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := cl.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token exchange: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("oidc token exchange: no id_token in response")
+	}
+
+	return body.IDToken, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token and
+// returns its claims.
+func (cl *Client) VerifyIDToken(c context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	provider, err := cl.Discover(c)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return cl.key(c, provider, kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyIssuer(provider.Issuer, true) || !claims.VerifyAudience(cl.clientID, true) {
+		return nil, ErrInvalidIDToken
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
+
+// key returns the provider's verification key with the given ID. The key set is refetched when
+// the ID is unknown, which is how providers roll their keys.
+func (cl *Client) key(c context.Context, provider *Provider, kid string) (interface{}, error) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if key, ok := cl.keys[kid]; ok && time.Now().Before(cl.keysUntil) {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := cl.getJSON(c, provider.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	cl.keys = map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			cl.keys[jwk.Kid] = key
+		}
+	}
+	cl.keysUntil = time.Now().Add(time.Hour)
+
+	key, ok := cl.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc jwks: unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (cl *Client) getJSON(c context.Context, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(c, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := cl.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", target, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// CodeChallenge derives the S256 PKCE challenge of a code verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
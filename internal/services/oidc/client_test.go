@@ -0,0 +1,344 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientID    = "one-to-one"
+	testRedirectURL = "https://app.example.com/auth/oidc/callback"
+)
+
+// mockIdP is an identity provider serving the discovery document, its signing keys and the
+// token endpoint. Codes are issued by authorize, which stands in for the user logging in at
+// the provider, and can be exchanged once with the code verifier matching their challenge.
+type mockIdP struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	key   *rsa.PrivateKey
+	kid   string
+	codes map[string]issuedCode
+}
+
+type issuedCode struct {
+	challenge string
+	idToken   string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	idp := &mockIdP{key: generateKey(t), kid: "key-1", codes: map[string]issuedCode{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(Provider{
+		Issuer:                idp.URL,
+		AuthorizationEndpoint: idp.URL + "/authorize",
+		TokenEndpoint:         idp.URL + "/token",
+		JWKSURI:               idp.URL + "/jwks",
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+		Kty: "RSA",
+		Kid: idp.kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+	}}})
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	idp.mu.Lock()
+	issued, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("client_id") != testClientID ||
+		r.PostForm.Get("redirect_uri") != testRedirectURL || CodeChallenge(r.PostForm.Get("code_verifier")) != issued.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "id_token": issued.idToken})
+}
+
+// authorize plays the user logging in at the provider for the authorization request URL. It
+// returns the code and state the provider redirects back with, the ID token of the code
+// carrying the request's nonce and the given claims.
+func (idp *mockIdP) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, idp.URL+"/authorize?") || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = query.Get("nonce")
+	}
+	code := "code-" + query.Get("state")
+	idToken := idp.idToken(t, claims)
+
+	idp.mu.Lock()
+	idp.codes[code] = issuedCode{challenge: query.Get("code_challenge"), idToken: idToken}
+	idp.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// idToken signs claims with the provider's current key, filling in the registered claims
+// that are left out.
+func (idp *mockIdP) idToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	idp.mu.Lock()
+	key, kid := idp.key, idp.kid
+	idp.mu.Unlock()
+	return signIDToken(t, idp.URL, claims, key, kid)
+}
+
+func signIDToken(t *testing.T, issuer string, claims jwt.MapClaims, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+
+	now := time.Now()
+	defaults := jwt.MapClaims{
+		"iss": issuer,
+		"aud": testClientID,
+		"sub": "subject-1",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+	for name, value := range defaults {
+		if _, ok := claims[name]; !ok {
+			claims[name] = value
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// rotate makes the provider sign with a new key, published under a new key ID.
+func (idp *mockIdP) rotate(t *testing.T) {
+	key := generateKey(t)
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key = key
+	idp.kid += "-rotated"
+}
+
+func (idp *mockIdP) client() *Client {
+	return NewClient(idp.URL+"/", testClientID, "", testRedirectURL, []string{"openid", "email", "profile"})
+}
+
+func TestClientAuthCodeURLUsesPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+
+	authURL, err := idp.client().AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if parsed.Query().Has("code_verifier") {
+		t.Errorf("the code verifier was sent to the browser")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// The example of RFC 7636, appendix B.
+	if got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %q", got)
+	}
+}
+
+func TestClientExchangeSendsCodeVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	cl := idp.client()
+	ctx := context.Background()
+
+	authURL, _ := cl.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	code, _ := idp.authorize(t, authURL, jwt.MapClaims{})
+	if _, err := cl.Exchange(ctx, code, "another-verifier"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Exchange with the wrong verifier = %v, want invalid_grant", err)
+	}
+
+	authURL, _ = cl.AuthCodeURL(ctx, "state-2", "nonce-2", "verifier-2")
+	code, _ = idp.authorize(t, authURL, jwt.MapClaims{})
+	rawIDToken, err := cl.Exchange(ctx, code, "verifier-2")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if _, err := cl.VerifyIDToken(ctx, rawIDToken, "nonce-2"); err != nil {
+		t.Errorf("VerifyIDToken: %v", err)
+	}
+
+	if _, err := cl.Exchange(ctx, code, "verifier-2"); err == nil {
+		t.Errorf("a code was exchanged twice")
+	}
+}
+
+func TestClientDiscoverRejectsOtherIssuer(t *testing.T) {
+	idp := newMockIdP(t)
+	cl := NewClient(idp.URL+"/tenant", testClientID, "", testRedirectURL, nil)
+
+	// The mock serves the same discovery document, naming another issuer, on every path.
+	idp.Config.Handler = http.HandlerFunc(idp.discovery)
+
+	if _, err := cl.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Discover = %v, want an issuer mismatch", err)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newMockIdP(t)
+	otherKey := generateKey(t)
+
+	tests := []struct {
+		name  string
+		token func() string
+		valid bool
+	}{
+		{"valid", func() string { return idp.idToken(t, jwt.MapClaims{"nonce": "n"}) }, true},
+		{"audience list", func() string {
+			return idp.idToken(t, jwt.MapClaims{"nonce": "n", "aud": []string{"other", testClientID}})
+		}, true},
+		{"other nonce", func() string { return idp.idToken(t, jwt.MapClaims{"nonce": "replayed"}) }, false},
+		{"no nonce", func() string { return idp.idToken(t, jwt.MapClaims{}) }, false},
+		{"other audience", func() string { return idp.idToken(t, jwt.MapClaims{"nonce": "n", "aud": "another-client"}) }, false},
+		{"other issuer", func() string { return idp.idToken(t, jwt.MapClaims{"nonce": "n", "iss": "https://evil.example.com"}) }, false},
+		{"expired", func() string {
+			return idp.idToken(t, jwt.MapClaims{"nonce": "n", "exp": time.Now().Add(-2 * clockSkew).Unix()})
+		}, false},
+		{"no subject", func() string { return idp.idToken(t, jwt.MapClaims{"nonce": "n", "sub": ""}) }, false},
+		{"bad signature", func() string { return signIDToken(t, idp.URL, jwt.MapClaims{"nonce": "n"}, otherKey, idp.kid) }, false},
+		{"unknown key", func() string { return signIDToken(t, idp.URL, jwt.MapClaims{"nonce": "n"}, otherKey, "unknown") }, false},
+		{"tampered payload", func() string {
+			parts := strings.Split(idp.idToken(t, jwt.MapClaims{"nonce": "n"}), ".")
+			payload, _ := json.Marshal(jwt.MapClaims{"iss": idp.URL, "aud": testClientID, "sub": "admin", "nonce": "n", "exp": time.Now().Add(time.Minute).Unix()})
+			parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+			return strings.Join(parts, ".")
+		}, false},
+		{"unsigned", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"iss": idp.URL, "aud": testClientID, "sub": "subject-1", "nonce": "n", "exp": time.Now().Add(time.Minute).Unix()})
+			signed, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}, false},
+		{"hmac", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": idp.URL, "aud": testClientID, "sub": "subject-1", "nonce": "n", "exp": time.Now().Add(time.Minute).Unix()})
+			token.Header["kid"] = idp.kid
+			signed, _ := token.SignedString([]byte(testClientID))
+			return signed
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := idp.client().VerifyIDToken(context.Background(), tt.token(), "n")
+			if tt.valid && (err != nil || claims.Subject != "subject-1") {
+				t.Errorf("VerifyIDToken = %+v, %v, want the claims", claims, err)
+			}
+			if !tt.valid && err != ErrInvalidIDToken {
+				t.Errorf("VerifyIDToken = %+v, %v, want ErrInvalidIDToken", claims, err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	idp := newMockIdP(t)
+	cl := idp.client()
+	ctx := context.Background()
+
+	if _, err := cl.VerifyIDToken(ctx, idp.idToken(t, jwt.MapClaims{"nonce": "n"}), "n"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+
+	idp.rotate(t)
+	if _, err := cl.VerifyIDToken(ctx, idp.idToken(t, jwt.MapClaims{"nonce": "n"}), "n"); err != nil {
+		t.Errorf("VerifyIDToken with the rotated key: %v", err)
+	}
+}
+
+func TestClaimsUsedMFA(t *testing.T) {
+	tests := map[string]bool{
+		`{"amr":["pwd","mfa"]}`: true,
+		`{"amr":["otp"]}`:       true,
+		`{"amr":["pwd"]}`:       false,
+		`{}`:                    false,
+	}
+	for raw, want := range tests {
+		var claims Claims
+		if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+			t.Fatal(err)
+		}
+		if got := claims.UsedMFA(); got != want {
+			t.Errorf("UsedMFA of %s = %v, want %v", raw, got, want)
+		}
+	}
+}
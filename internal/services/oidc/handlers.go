@@ -0,0 +1,234 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+	"one-to-one/internal/tenant"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// stateExpire is how long a user has to complete the login at the identity provider.
+const stateExpire = 10 * time.Minute
+
+var errUnverifiedEmail = errors.New("the identity provider did not return a verified email address")
+
+type OIDCHandler struct {
+	Client   *Client
+	UserRepo user.UserRepository
+	AuthRepo auth.AuthRepository
+	OrgRepo  organisation.OrganisationRepository
+}
+
+func NewOIDCHandler(client *Client, userRepo user.UserRepository, authRepo auth.AuthRepository, orgRepo organisation.OrganisationRepository) *OIDCHandler {
+	return &OIDCHandler{Client: client, UserRepo: userRepo, AuthRepo: authRepo, OrgRepo: orgRepo}
+}
+
+// @Summary Start OIDC login
+// @Description Redirect to the corporate identity provider using the authorization code flow with PKCE. Only the first organisation logs in with the identity provider.
+// @Tags auth
+// @Produce json
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} map[string]interface{} "OIDC login is not configured, or not available for this organisation"
+// @Failure 502 {object} map[string]interface{} "Identity provider unavailable"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if !h.Client.Configured() {
+		api.Error(c, http.StatusNotFound, "OIDC login is not configured", nil)
+		return
+	}
+
+	state, err1 := auth.GenerateOpaqueToken()
+	nonce, err2 := auth.GenerateOpaqueToken()
+	codeVerifier, err3 := auth.GenerateOpaqueToken()
+	if err1 != nil || err2 != nil || err3 != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	now := time.Now()
+	organisationID, _ := tenant.OrganisationID(c.Request.Context())
+	err := h.AuthRepo.CreateOIDCState(c.Request.Context(), auth.OIDCState{
+		ID:             primitive.NewObjectID(),
+		OrganisationID: organisationID,
+		StateHash:      auth.HashToken(state),
+		Nonce:          nonce,
+		CodeVerifier:   codeVerifier,
+		ExpiresAt:      primitive.NewDateTimeFromTime(now.Add(stateExpire)),
+		CreatedAt:      primitive.NewDateTimeFromTime(now),
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	redirectURL, err := h.Client.AuthCodeURL(c.Request.Context(), state, nonce, codeVerifier)
+	if err != nil {
+		log.Println("OIDC login failed: ", err)
+		api.Error(c, http.StatusBadGateway, "Identity provider unavailable", nil)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// @Summary Finish OIDC login
+// @Description Exchange the authorization code returned by the identity provider for the app tokens. Unknown users are provisioned from the ID token claims.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the identity provider"
+// @Success 200 {object} user.LoginResponse "User logged in successfully"
+// @Success 202 {object} user.MFAChallengeResponse "The identity provider did not ask for a second factor, a TOTP code is required to finish logging in"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, or invalid or expired state"
+// @Failure 401 {object} map[string]interface{} "Login rejected by the identity provider"
+// @Failure 403 {object} map[string]interface{} "No verified email address, or account has been deactivated"
+// @Failure 404 {object} map[string]interface{} "OIDC login is not configured, or not available for this organisation"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if !h.Client.Configured() {
+		api.Error(c, http.StatusNotFound, "OIDC login is not configured", nil)
+		return
+	}
+
+	if idpError := c.Query("error"); idpError != "" {
+		api.Error(c, http.StatusUnauthorized, "Login rejected by the identity provider: "+idpError, nil)
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		api.Error(c, http.StatusBadRequest, "Missing code or state", nil)
+		return
+	}
+
+	storedState, err := h.AuthRepo.ConsumeOIDCState(c.Request.Context(), auth.HashToken(state))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired login state", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	rawIDToken, err := h.Client.Exchange(c.Request.Context(), code, storedState.CodeVerifier)
+	if err != nil {
+		log.Println("OIDC code exchange failed: ", err)
+		api.Error(c, http.StatusUnauthorized, "Login rejected by the identity provider", nil)
+		return
+	}
+
+	claims, err := h.Client.VerifyIDToken(c.Request.Context(), rawIDToken, storedState.Nonce)
+	if err != nil {
+		log.Println("OIDC ID token rejected: ", err)
+		api.Error(c, http.StatusUnauthorized, "Login rejected by the identity provider", nil)
+		return
+	}
+
+	// The identity provider redirects back without the tenant, the state remembers it.
+	ctx := c.Request.Context()
+	if !storedState.OrganisationID.IsZero() {
+		ctx = tenant.WithOrganisation(ctx, storedState.OrganisationID)
+	}
+
+	account, err := h.provisionUser(ctx, claims)
+	if err != nil {
+		if err == errUnverifiedEmail {
+			api.Error(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if !account.Active() {
+		api.Error(c, http.StatusForbidden, "Account has been deactivated", nil)
+		return
+	}
+
+	// A second factor enforced by the identity provider counts as our own. Without one, users
+	// who enrolled in TOTP finish the login with it like after a password.
+	if account.MFAEnabled() && !claims.UsedMFA() {
+		user.SendMFAChallenge(c, *account)
+		return
+	}
+
+	tokens, err := user.IssueTokens(ctx, h.AuthRepo, *account, user.NewTokenOptions(c, claims.UsedMFA()))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "User logged in successfully", user.ConvertToLoginResponse(tokens, *account))
+}
+
+// provisionUser finds the user an ID token belongs to, creating the account on first login.
+// Accounts are matched on the identity provider's subject, and the first time on a verified
+// email address, so that an unverified email can never take over an existing account.
+func (h *OIDCHandler) provisionUser(c context.Context, claims *Claims) (*user.User, error) {
+	subject := claims.Issuer + "|" + claims.Subject
+
+	existing, err := h.UserRepo.GetUserByOIDCSubject(c, subject)
+	if err == nil {
+		return existing, nil
+	} else if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return nil, errUnverifiedEmail
+	}
+	existing, err = h.UserRepo.GetUserByEmail(c, claims.Email)
+	if err == nil {
+		if err := h.UserRepo.SetOIDCSubject(c, existing.ID, subject); err != nil {
+			return nil, err
+		}
+		if !existing.EmailVerified {
+			if err := h.UserRepo.MarkEmailVerified(c, existing.ID, existing.Email); err != nil {
+				return nil, err
+			}
+			existing.EmailVerified = true
+		}
+		return existing, nil
+	} else if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+
+	newUser := user.NewProvisionedUser(claims.Email, firstName, lastName)
+	now := primitive.NewDateTimeFromTime(time.Now())
+	newUser.OIDCSubject = subject
+	newUser.EmailVerified = true
+	newUser.EmailVerifiedAt = &now
+	newUser.CreatedAt = now
+	newUser.UpdatedAt = now
+
+	created, err := h.UserRepo.CreateUser(c, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := user.AssignDefaultManager(c, h.UserRepo, h.OrgRepo, &created); err != nil {
+		log.Println("Failed to assign the default manager: ", err)
+	}
+
+	return &created, nil
+}
@@ -0,0 +1,363 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+)
+
+// fakeUserRepo keeps users in memory. Methods the login does not use are left to the embedded
+// nil interface.
+type fakeUserRepo struct {
+	user.UserRepository
+	users   []*user.User
+	created int
+}
+
+func (r *fakeUserRepo) GetUserByOIDCSubject(c context.Context, subject string) (*user.User, error) {
+	for _, account := range r.users {
+		if account.OIDCSubject == subject {
+			found := *account
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeUserRepo) GetUserByEmail(c context.Context, email string) (*user.User, error) {
+	for _, account := range r.users {
+		if strings.EqualFold(account.Email, email) {
+			found := *account
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeUserRepo) SetOIDCSubject(c context.Context, userID primitive.ObjectID, subject string) error {
+	r.byID(userID).OIDCSubject = subject
+	return nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(c context.Context, userID primitive.ObjectID, email string) error {
+	r.byID(userID).EmailVerified = true
+	return nil
+}
+
+func (r *fakeUserRepo) CreateUser(c context.Context, account user.User) (user.User, error) {
+	r.users = append(r.users, &account)
+	r.created++
+	return account, nil
+}
+
+func (r *fakeUserRepo) byID(id primitive.ObjectID) *user.User {
+	for _, account := range r.users {
+		if account.ID == id {
+			return account
+		}
+	}
+	return nil
+}
+
+// fakeAuthRepo keeps login states in memory and accepts every session.
+type fakeAuthRepo struct {
+	auth.AuthRepository
+	states   map[string]auth.OIDCState
+	sessions int
+}
+
+func (r *fakeAuthRepo) CreateOIDCState(c context.Context, state auth.OIDCState) error {
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeAuthRepo) ConsumeOIDCState(c context.Context, stateHash string) (*auth.OIDCState, error) {
+	state, ok := r.states[stateHash]
+	if !ok || state.ExpiresAt.Time().Before(time.Now()) {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.states, stateHash)
+	return &state, nil
+}
+
+func (r *fakeAuthRepo) CreateSession(c context.Context, session auth.Session) error {
+	r.sessions++
+	return nil
+}
+
+func (r *fakeAuthRepo) CreateRefreshToken(c context.Context, token auth.RefreshToken) error {
+	return nil
+}
+
+// fakeOrgRepo answers as before the setup, when there is no default manager to assign.
+type fakeOrgRepo struct {
+	organisation.OrganisationRepository
+}
+
+func (r *fakeOrgRepo) GetOrganisation(c context.Context) (*organisation.Organisation, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+type callbackFixture struct {
+	idp      *mockIdP
+	users    *fakeUserRepo
+	authRepo *fakeAuthRepo
+	router   *gin.Engine
+}
+
+func newCallbackFixture(t *testing.T) *callbackFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	config.AppConfig().App.Environment = "local"
+	config.AppConfig().Auth.JWTSecret = "oidc-test-secret"
+	config.AppConfig().Auth.ShortTokenExpire = 15
+	config.AppConfig().Auth.MFAChallengeExpire = 5
+
+	f := &callbackFixture{
+		idp:      newMockIdP(t),
+		users:    &fakeUserRepo{},
+		authRepo: &fakeAuthRepo{states: map[string]auth.OIDCState{}},
+		router:   gin.New(),
+	}
+	handler := NewOIDCHandler(f.idp.client(), f.users, f.authRepo, &fakeOrgRepo{})
+	f.router.GET("/auth/oidc/login", handler.Login)
+	f.router.GET("/auth/oidc/callback", handler.Callback)
+	return f
+}
+
+func (f *callbackFixture) get(target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+// login starts a login, lets the user log in at the provider with the given ID token claims
+// and returns the code and state the provider redirects back with.
+func (f *callbackFixture) login(t *testing.T, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	w := f.get("/auth/oidc/login")
+	if w.Code != http.StatusFound {
+		t.Fatalf("login answered %d: %s", w.Code, w.Body)
+	}
+	return f.idp.authorize(t, w.Header().Get("Location"), claims)
+}
+
+func (f *callbackFixture) callback(code string, state string) *httptest.ResponseRecorder {
+	return f.get("/auth/oidc/callback?code=" + url.QueryEscape(code) + "&state=" + url.QueryEscape(state))
+}
+
+func verifiedEmail(email string) jwt.MapClaims {
+	return jwt.MapClaims{"email": email, "email_verified": true, "given_name": "Grace", "family_name": "Hopper"}
+}
+
+func TestCallbackProvisionsNewUser(t *testing.T) {
+	f := newCallbackFixture(t)
+
+	w := f.callback(f.login(t, verifiedEmail("grace@example.com")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback answered %d: %s", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), `"refreshToken"`) {
+		t.Errorf("no tokens in the response: %s", w.Body)
+	}
+
+	if f.users.created != 1 {
+		t.Fatalf("created %d users, want 1", f.users.created)
+	}
+	created := f.users.users[0]
+	if created.Email != "grace@example.com" || !created.EmailVerified || created.FirstName != "Grace" || created.OIDCSubject != f.idp.URL+"|subject-1" {
+		t.Errorf("provisioned user %+v", created)
+	}
+
+	// The next login finds the user by subject, even with another email address.
+	if w := f.callback(f.login(t, verifiedEmail("grace.hopper@example.com"))); w.Code != http.StatusOK {
+		t.Fatalf("second callback answered %d: %s", w.Code, w.Body)
+	}
+	if f.users.created != 1 || f.authRepo.sessions != 2 {
+		t.Errorf("created %d users and %d sessions, want 1 and 2", f.users.created, f.authRepo.sessions)
+	}
+}
+
+func TestCallbackChecksState(t *testing.T) {
+	f := newCallbackFixture(t)
+	code, state := f.login(t, verifiedEmail("grace@example.com"))
+
+	if w := f.callback(code, "forged-state"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown state answered %d, want 400", w.Code)
+	}
+	if w := f.get("/auth/oidc/callback?code=" + code); w.Code != http.StatusBadRequest {
+		t.Errorf("missing state answered %d, want 400", w.Code)
+	}
+	if w := f.callback(code, state); w.Code != http.StatusOK {
+		t.Fatalf("callback answered %d: %s", w.Code, w.Body)
+	}
+	if w := f.callback(code, state); w.Code != http.StatusBadRequest {
+		t.Errorf("reused state answered %d, want 400", w.Code)
+	}
+
+	code, state = f.login(t, verifiedEmail("grace@example.com"))
+	for hash, stored := range f.authRepo.states {
+		stored.ExpiresAt = primitive.NewDateTimeFromTime(time.Now().Add(-time.Second))
+		f.authRepo.states[hash] = stored
+	}
+	if w := f.callback(code, state); w.Code != http.StatusBadRequest {
+		t.Errorf("expired state answered %d, want 400", w.Code)
+	}
+}
+
+func TestCallbackSendsStoredCodeVerifier(t *testing.T) {
+	f := newCallbackFixture(t)
+	code, state := f.login(t, verifiedEmail("grace@example.com"))
+
+	// Another verifier than the one the challenge was derived from is refused by the provider.
+	stored := f.authRepo.states[auth.HashToken(state)]
+	stored.CodeVerifier = "intercepted"
+	f.authRepo.states[auth.HashToken(state)] = stored
+
+	if w := f.callback(code, state); w.Code != http.StatusUnauthorized {
+		t.Errorf("callback answered %d, want 401", w.Code)
+	}
+	if f.users.created != 0 {
+		t.Errorf("a user was provisioned")
+	}
+}
+
+func TestCallbackRejectsInvalidIDTokens(t *testing.T) {
+	tests := map[string]jwt.MapClaims{
+		"other nonce":    {"nonce": "from-another-login"},
+		"other audience": {"aud": "another-client"},
+		"other issuer":   {"iss": "https://evil.example.com"},
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+	}
+	for name, overrides := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := newCallbackFixture(t)
+			claims := verifiedEmail("grace@example.com")
+			for claim, value := range overrides {
+				claims[claim] = value
+			}
+
+			if w := f.callback(f.login(t, claims)); w.Code != http.StatusUnauthorized {
+				t.Errorf("callback answered %d, want 401", w.Code)
+			}
+			if f.users.created != 0 || f.authRepo.sessions != 0 {
+				t.Errorf("a rejected ID token logged a user in")
+			}
+		})
+	}
+
+	t.Run("bad signature", func(t *testing.T) {
+		f := newCallbackFixture(t)
+		code, state := f.login(t, verifiedEmail("grace@example.com"))
+
+		// The provider's published key no longer matches the one the ID token was signed with.
+		f.idp.mu.Lock()
+		issued := f.idp.codes[code]
+		issued.idToken = signIDToken(t, f.idp.URL, jwt.MapClaims{"nonce": f.authRepo.states[auth.HashToken(state)].Nonce}, generateKey(t), f.idp.kid)
+		f.idp.codes[code] = issued
+		f.idp.mu.Unlock()
+
+		if w := f.callback(code, state); w.Code != http.StatusUnauthorized {
+			t.Errorf("callback answered %d, want 401", w.Code)
+		}
+	})
+}
+
+func TestCallbackLinksVerifiedEmail(t *testing.T) {
+	f := newCallbackFixture(t)
+	existing := user.NewProvisionedUser("grace@example.com", "Grace", "Hopper")
+	f.users.users = append(f.users.users, &existing)
+
+	if w := f.callback(f.login(t, verifiedEmail("Grace@Example.com"))); w.Code != http.StatusOK {
+		t.Fatalf("callback answered %d: %s", w.Code, w.Body)
+	}
+
+	if f.users.created != 0 {
+		t.Errorf("a new user was created instead of linking the existing one")
+	}
+	if existing.OIDCSubject != f.idp.URL+"|subject-1" || !existing.EmailVerified {
+		t.Errorf("existing user not linked: %+v", existing)
+	}
+}
+
+func TestCallbackRejectsUnverifiedEmail(t *testing.T) {
+	for name, verified := range map[string]interface{}{"false": false, "string": "false", "missing": nil} {
+		t.Run(name, func(t *testing.T) {
+			f := newCallbackFixture(t)
+			existing := user.NewProvisionedUser("grace@example.com", "Grace", "Hopper")
+			f.users.users = append(f.users.users, &existing)
+
+			claims := jwt.MapClaims{"email": "grace@example.com"}
+			if verified != nil {
+				claims["email_verified"] = verified
+			}
+			if w := f.callback(f.login(t, claims)); w.Code != http.StatusForbidden {
+				t.Errorf("callback answered %d, want 403", w.Code)
+			}
+			if existing.OIDCSubject != "" || f.users.created != 0 || f.authRepo.sessions != 0 {
+				t.Errorf("an unverified email was linked or logged in")
+			}
+		})
+	}
+
+	t.Run("verified as a string", func(t *testing.T) {
+		f := newCallbackFixture(t)
+		claims := jwt.MapClaims{"email": "grace@example.com", "email_verified": "true"}
+		if w := f.callback(f.login(t, claims)); w.Code != http.StatusOK {
+			t.Errorf("callback answered %d: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestCallbackRejectsDeactivatedUser(t *testing.T) {
+	f := newCallbackFixture(t)
+	existing := user.NewProvisionedUser("grace@example.com", "Grace", "Hopper")
+	now := primitive.NewDateTimeFromTime(time.Now())
+	existing.DeactivatedAt = &now
+	f.users.users = append(f.users.users, &existing)
+
+	if w := f.callback(f.login(t, verifiedEmail("grace@example.com"))); w.Code != http.StatusForbidden {
+		t.Errorf("callback answered %d, want 403", w.Code)
+	}
+	if f.authRepo.sessions != 0 {
+		t.Errorf("a deactivated user was logged in")
+	}
+}
+
+func TestCallbackAsksForTOTP(t *testing.T) {
+	f := newCallbackFixture(t)
+	existing := user.NewProvisionedUser("grace@example.com", "Grace", "Hopper")
+	existing.OIDCSubject = f.idp.URL + "|subject-1"
+	existing.MFA = &user.MFASettings{Enabled: true, Secret: "JBSWY3DPEHPK3PXP"}
+	f.users.users = append(f.users.users, &existing)
+
+	w := f.callback(f.login(t, verifiedEmail("grace@example.com")))
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"mfaToken"`) {
+		t.Errorf("callback answered %d %s, want an MFA challenge", w.Code, w.Body)
+	}
+	if f.authRepo.sessions != 0 {
+		t.Errorf("a session was started before the second factor")
+	}
+
+	// A second factor asked by the identity provider is enough.
+	claims := verifiedEmail("grace@example.com")
+	claims["amr"] = []string{"pwd", "mfa"}
+	if w := f.callback(f.login(t, claims)); w.Code != http.StatusOK {
+		t.Errorf("callback with amr mfa answered %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Claims are the ID token claims used to log a user in.
+type Claims struct {
+	Issuer        string       `json:"iss"`
+	Subject       string       `json:"sub"`
+	Audience      audience     `json:"aud"`
+	ExpiresAt     int64        `json:"exp"`
+	IssuedAt      int64        `json:"iat"`
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	GivenName     string       `json:"given_name"`
+	FamilyName    string       `json:"family_name"`
+	Name          string       `json:"name"`
+	AMR           []string     `json:"amr"`
+}
+
+// UsedMFA reports whether the identity provider says the user authenticated with more than
+// one factor, using the authentication method references of RFC 8176.
+func (c *Claims) UsedMFA() bool {
+	for _, method := range c.AMR {
+		switch method {
+		case "mfa", "otp", "hwk", "swk", "sms":
+			return true
+		}
+	}
+	return false
+}
+
+// clockSkew is the leeway allowed between our clock and the identity provider's.
+const clockSkew = time.Minute
+
+// Valid implements jwt.Claims.
+func (c *Claims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return errors.New("token is expired")
+	}
+	if c.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.New("token used before issued")
+	}
+	return nil
+}
+
+func (c *Claims) VerifyIssuer(issuer string, required bool) bool {
+	if c.Issuer == "" {
+		return !required
+	}
+	return c.Issuer == issuer
+}
+
+func (c *Claims) VerifyAudience(clientID string, required bool) bool {
+	if len(c.Audience) == 0 {
+		return !required
+	}
+	for _, aud := range c.Audience {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// audience accepts the "aud" claim both as a single string and as a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// flexibleBool accepts booleans encoded as JSON strings, which some providers send for
+// "email_verified".
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexibleBool(value)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*b = str == "true"
+	return nil
+}
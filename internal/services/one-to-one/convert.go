@@ -1,5 +1,11 @@
 package one_to_one
 
+import (
+	user "one-to-one/internal/services/user"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 func CleanCreateWeeklyReportRequest(req *CreateWeeklyReportRequest) {
 	req.GoneWell = FilterEmptyLabels(req.GoneWell, func(g GoneWell) string {
 		return g.Label
@@ -17,3 +23,59 @@ func CleanUpdateWeeklyReportRequest(req *UpdateWeeklyReportRequest) {
 		return c.Label
 	})
 }
+
+// RedactForSkipLevel removes the free-text items the reportee has not shared with skip-level
+// managers. The wellbeing scores are always shared.
+func RedactForSkipLevel(report WeeklyReport) WeeklyReport {
+	report.Agendas = FilterSkipLevelVisible(report.Agendas, func(a Agenda) bool {
+		return a.SkipLevelVisible
+	})
+	report.GoneWell = FilterSkipLevelVisible(report.GoneWell, func(g GoneWell) bool {
+		return g.SkipLevelVisible
+	})
+	report.Challenges = FilterSkipLevelVisible(report.Challenges, func(c Challenges) bool {
+		return c.SkipLevelVisible
+	})
+	return report
+}
+
+// KeepReporteeVisibility stops a manager's update from changing which items the reportee shares
+// with skip-level managers.
+func KeepReporteeVisibility(req *UpdateWeeklyReportRequest, stored WeeklyReport) {
+	req.Agendas = KeepSkipLevelVisibility(req.Agendas, stored.Agendas,
+		func(a Agenda) string { return a.Label },
+		func(a Agenda) bool { return a.SkipLevelVisible },
+		func(a Agenda, visible bool) Agenda { a.SkipLevelVisible = visible; return a })
+	req.GoneWell = KeepSkipLevelVisibility(req.GoneWell, stored.GoneWell,
+		func(g GoneWell) string { return g.Label },
+		func(g GoneWell) bool { return g.SkipLevelVisible },
+		func(g GoneWell, visible bool) GoneWell { g.SkipLevelVisible = visible; return g })
+	req.Challenges = KeepSkipLevelVisibility(req.Challenges, stored.Challenges,
+		func(c Challenges) string { return c.Label },
+		func(c Challenges) bool { return c.SkipLevelVisible },
+		func(c Challenges, visible bool) Challenges { c.SkipLevelVisible = visible; return c })
+}
+
+// ConvertToWeeklyReportResponse adds the summaries of the reportee and the manager to a report.
+func ConvertToWeeklyReportResponse(report WeeklyReport, summaries map[primitive.ObjectID]user.UserSummary) WeeklyReportResponse {
+	response := WeeklyReportResponse{
+		ID:              report.ID,
+		Reportee:        report.Reportee,
+		ReportingTo:     report.ReportingTo,
+		Week:            report.Week,
+		Year:            report.Year,
+		WellbeingScores: report.WellbeingScores,
+		Agendas:         report.Agendas,
+		GoneWell:        report.GoneWell,
+		Challenges:      report.Challenges,
+		CreatedAt:       report.CreatedAt.Time(),
+		UpdatedAt:       report.UpdatedAt.Time(),
+	}
+	if summary, ok := summaries[report.Reportee]; ok {
+		response.ReporteeSummary = &summary
+	}
+	if summary, ok := summaries[report.ReportingTo]; ok {
+		response.ReportingToSummary = &summary
+	}
+	return response
+}
@@ -1,8 +1,10 @@
 package one_to_one
 
 import (
+	"context"
 	"net/http"
 	"one-to-one/internal/api"
+	user "one-to-one/internal/services/user"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +13,54 @@ import (
 )
 
 type OneToOneHandler struct {
-	Repo OneToOneRepository
+	Repo     OneToOneRepository
+	UserRepo user.UserRepository
 }
 
-func NewOneToOneHandler(repo OneToOneRepository) *OneToOneHandler {
-	return &OneToOneHandler{Repo: repo}
+func NewOneToOneHandler(repo OneToOneRepository, userRepo user.UserRepository) *OneToOneHandler {
+	return &OneToOneHandler{Repo: repo, UserRepo: userRepo}
+}
+
+// convertReports looks up the reportees and managers of the reports, so that they can be shown
+// with their names and avatars.
+func (h *OneToOneHandler) convertReports(c context.Context, reports []WeeklyReport) ([]WeeklyReportResponse, error) {
+	ids := make([]primitive.ObjectID, 0, 2*len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.Reportee, report.ReportingTo)
+	}
+
+	summaries, err := h.UserRepo.GetUserSummaries(c, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]WeeklyReportResponse, len(reports))
+	for i, report := range reports {
+		responses[i] = ConvertToWeeklyReportResponse(report, summaries)
+	}
+	return responses, nil
+}
+
+// sendReport answers with a single report, see convertReports.
+func (h *OneToOneHandler) sendReport(c *gin.Context, status int, message string, report WeeklyReport) {
+	responses, err := h.convertReports(c.Request.Context(), []WeeklyReport{report})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, status, message, responses[0])
+}
+
+// sendReports answers with a list of reports, see convertReports.
+func (h *OneToOneHandler) sendReports(c *gin.Context, message string, reports []WeeklyReport) {
+	responses, err := h.convertReports(c.Request.Context(), reports)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, message, responses)
 }
 
 // @Summary Create a new weekly report
@@ -26,6 +71,7 @@ func NewOneToOneHandler(repo OneToOneRepository) *OneToOneHandler {
 // @Param report body CreateWeeklyReportRequest true "Weekly report object to be created"
 // @Success 201 {object} WeeklyReportResponse "Weekly report created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 409 {object} map[string]interface{} "The user has no manager to report to"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /one-to-one/create [post]
 func (h *OneToOneHandler) CreateWeeklyReport(c *gin.Context) {
@@ -45,11 +91,15 @@ func (h *OneToOneHandler) CreateWeeklyReport(c *gin.Context) {
 
 	createdReport, err := h.Repo.CreateWeeklyReport(c.Request.Context(), reqPayload, userID)
 	if err != nil {
+		if err == ErrNoManager {
+			api.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
 		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	api.Success(c, http.StatusCreated, "Created weekly report successfully", createdReport)
+	h.sendReport(c, http.StatusCreated, "Created weekly report successfully", createdReport)
 }
 
 // @Summary Get all weekly reports for a reportee
@@ -74,7 +124,7 @@ func (h *OneToOneHandler) GetAllWeeklyReportsForReportee(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Fetched all weekly reports successfully", reports)
+	h.sendReports(c, "Fetched all weekly reports successfully", reports)
 }
 
 // @Summary Update a weekly report for a reportee
@@ -108,7 +158,7 @@ func (h *OneToOneHandler) UpdateWeeklyReportForReportee(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Updated weekly report successfully", updatedReport)
+	h.sendReport(c, http.StatusOK, "Updated weekly report successfully", updatedReport)
 }
 
 // @Summary Get a weekly report by week and year for a reportee
@@ -149,7 +199,7 @@ func (h *OneToOneHandler) GetWeeklyReportByWeekAndYearForReportee(c *gin.Context
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Fetched weekly report successfully", report)
+	h.sendReport(c, http.StatusOK, "Fetched weekly report successfully", report)
 }
 
 // @Summary Get all weekly reports for a reportTo
@@ -174,7 +224,7 @@ func (h *OneToOneHandler) GetAllWeeklyReportsForReportTo(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Fetched all weekly reports successfully", reports)
+	h.sendReports(c, "Fetched all weekly reports successfully", reports)
 }
 
 // @Summary Update a weekly report for a reportTo
@@ -206,7 +256,7 @@ func (h *OneToOneHandler) UpdateWeeklyReportForReportTo(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Updated weekly report successfully", updatedReport)
+	h.sendReport(c, http.StatusOK, "Updated weekly report successfully", updatedReport)
 }
 
 // @Summary Get a weekly report by week and year for a reportTo
@@ -247,5 +297,46 @@ func (h *OneToOneHandler) GetWeeklyReportByWeekAndYearForReportTo(c *gin.Context
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Fetched weekly report successfully", report)
+	h.sendReport(c, http.StatusOK, "Fetched weekly report successfully", report)
+}
+
+// @Summary Get the weekly reports of everyone below a manager
+// @Description Get the weekly reports of the direct and indirect reportees of the current user. Reports written to another manager only include the items their reportee shared with skip-level managers.
+// @Tags one-to-one
+// @Accept json
+// @Produce json
+// @Param reportee query string false "Only return the reports of this reportee"
+// @Success 200 {array} WeeklyReportResponse "List of weekly reports"
+// @Failure 400 {object} map[string]interface{} "Invalid reportee ID"
+// @Failure 403 {object} map[string]interface{} "The reportee is not below the current user"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /one-to-one/skip-level/all [get]
+func (h *OneToOneHandler) GetAllWeeklyReportsForSkipLevel(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var reporteeID *primitive.ObjectID
+	if reporteeStr := c.Query("reportee"); reporteeStr != "" {
+		id, err := primitive.ObjectIDFromHex(reporteeStr)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, "Invalid reportee ID", nil)
+			return
+		}
+		reporteeID = &id
+	}
+
+	reports, err := h.Repo.GetSkipLevelWeeklyReports(c.Request.Context(), userID, reporteeID)
+	if err != nil {
+		if err == ErrNotInReportingLine {
+			api.Error(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	h.sendReports(c, "Fetched all weekly reports successfully", reports)
 }
@@ -1,6 +1,7 @@
 package one_to_one
 
 import (
+	user "one-to-one/internal/services/user"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,17 +16,20 @@ type WellbeingScores struct {
 }
 
 type GoneWell struct {
-	Label string `json:"label" bson:"label" validate:"required"`
-	Theme string `json:"theme" bson:"theme" validate:"required"`
+	Label            string `json:"label" bson:"label" validate:"required"`
+	Theme            string `json:"theme" bson:"theme" validate:"required"`
+	SkipLevelVisible bool   `json:"skipLevelVisible" bson:"skipLevelVisible"`
 }
 
 type Challenges struct {
-	Label string `json:"label" bson:"label" validate:"required"`
-	Theme string `json:"theme" bson:"theme" validate:"required"`
+	Label            string `json:"label" bson:"label" validate:"required"`
+	Theme            string `json:"theme" bson:"theme" validate:"required"`
+	SkipLevelVisible bool   `json:"skipLevelVisible" bson:"skipLevelVisible"`
 }
 
 type Agenda struct {
-	Label string `json:"label" bson:"label" validate:"required"`
+	Label            string `json:"label" bson:"label" validate:"required"`
+	SkipLevelVisible bool   `json:"skipLevelVisible" bson:"skipLevelVisible"`
 }
 
 type UserSummary struct {
@@ -62,18 +66,22 @@ type UpdateWeeklyReportRequest struct {
 // ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
 // ---------------------------------------------------------------------------------------------------
 
+// WeeklyReportResponse is a weekly report along with the reportee and the manager it was
+// written to. Their summaries are left out when the user no longer exists.
 type WeeklyReportResponse struct {
-	ID              primitive.ObjectID `json:"id,omitempty"`
-	Reportee        primitive.ObjectID `json:"reportee"`
-	ReportingTo     primitive.ObjectID `json:"reportingTo"`
-	Week            int                `json:"week"`
-	Year            int                `json:"year"`
-	WellbeingScores WellbeingScores    `json:"wellbeingScores"`
-	Agendas         []Agenda           `json:"agendas"`
-	GoneWell        []GoneWell         `json:"goneWell"`
-	Challenges      []Challenges       `json:"challenges"`
-	CreatedAt       time.Time          `json:"createdAt,omitempty"`
-	UpdatedAt       time.Time          `json:"updatedAt,omitempty"`
+	ID                 primitive.ObjectID `json:"id,omitempty"`
+	Reportee           primitive.ObjectID `json:"reportee"`
+	ReportingTo        primitive.ObjectID `json:"reportingTo"`
+	ReporteeSummary    *user.UserSummary  `json:"reporteeSummary,omitempty"`
+	ReportingToSummary *user.UserSummary  `json:"reportingToSummary,omitempty"`
+	Week               int                `json:"week"`
+	Year               int                `json:"year"`
+	WellbeingScores    WellbeingScores    `json:"wellbeingScores"`
+	Agendas            []Agenda           `json:"agendas"`
+	GoneWell           []GoneWell         `json:"goneWell"`
+	Challenges         []Challenges       `json:"challenges"`
+	CreatedAt          time.Time          `json:"createdAt,omitempty"`
+	UpdatedAt          time.Time          `json:"updatedAt,omitempty"`
 }
 
 // ---------------------------------------------------------------------------------------------------
@@ -82,6 +90,7 @@ type WeeklyReportResponse struct {
 
 type WeeklyReport struct {
 	ID              primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty" validate:"required"`
+	OrganisationID  primitive.ObjectID `json:"-" bson:"organisationId,omitempty"`
 	Reportee        primitive.ObjectID `json:"reportee" bson:"reportee" validate:"required"`
 	ReportingTo     primitive.ObjectID `json:"reportingTo" bson:"reportingTo" validate:"required"`
 	Week            int                `json:"week" bson:"week" validate:"required"`
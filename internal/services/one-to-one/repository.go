@@ -2,9 +2,10 @@ package one_to_one
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"one-to-one/internal/db"
 	user "one-to-one/internal/services/user"
+	"one-to-one/internal/tenant"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,9 +14,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrNoManager is returned when a reportee without a manager writes a weekly report.
+var ErrNoManager = errors.New("you have no manager to report to")
+
+// ErrNotInReportingLine is returned when a manager asks for the reports of a user who is not
+// below them in the reporting lines.
+var ErrNotInReportingLine = errors.New("the user does not report to you directly or indirectly")
+
 type OneToOneRepository interface {
 	CreateWeeklyReport(c context.Context, report CreateWeeklyReportRequest, currentUserId primitive.ObjectID) (WeeklyReport, error)
 	GetAllWeeklyReports(c context.Context, currentUserId primitive.ObjectID, isReportee bool) ([]WeeklyReport, error)
+	GetSkipLevelWeeklyReports(c context.Context, currentUserId primitive.ObjectID, reporteeID *primitive.ObjectID) ([]WeeklyReport, error)
 	UpdateWeeklyReport(c context.Context, report UpdateWeeklyReportRequest, currentUserId primitive.ObjectID, isReportee bool) (WeeklyReport, error)
 	GetWeeklyReportByWeekAndYear(c context.Context, week int, year int, currentUserId primitive.ObjectID, isReportee bool) (WeeklyReport, error)
 }
@@ -34,24 +43,20 @@ func NewOneToOneRepository() OneToOneRepository {
 func (r *repositoryImpl) CreateWeeklyReport(c context.Context, report CreateWeeklyReportRequest, currentUserId primitive.ObjectID) (WeeklyReport, error) {
 
 	var reportee user.User
-	err := r.userCollection.FindOne(c, bson.M{"_id": currentUserId}).Decode(&reportee)
+	err := r.userCollection.FindOne(c, tenant.Filter(c, bson.M{"_id": currentUserId})).Decode(&reportee)
 	if err != nil {
 		return WeeklyReport{}, err
 	}
 
-	// Find reportingTo user by ID
-	var reportingTo user.User
-	err = r.userCollection.FindOne(c, bson.M{"_id": reportee.ReportsTo}).Decode(&reportingTo)
+	reportingTo, err := r.manager(c, reportee)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return WeeklyReport{}, fmt.Errorf("no user to report to")
-		}
 		return WeeklyReport{}, err
 	}
 
-	// Create WeeklyReport
+	// Create WeeklyReport in the organisation of the reportee
 	mongoReport := WeeklyReport{
 		ID:              primitive.NewObjectID(),
+		OrganisationID:  reportee.OrganisationID,
 		Reportee:        reportee.ID,
 		ReportingTo:     reportingTo.ID,
 		Week:            report.Week,
@@ -86,7 +91,7 @@ func (r *repositoryImpl) GetAllWeeklyReports(c context.Context, currentUserId pr
 		{Key: "week", Value: -1},
 	})
 
-	cursor, err := r.collection.Find(c, filter, findOptions)
+	cursor, err := r.collection.Find(c, tenant.Filter(c, filter), findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -100,27 +105,71 @@ func (r *repositoryImpl) GetAllWeeklyReports(c context.Context, currentUserId pr
 	return reports, nil
 }
 
-func (r *repositoryImpl) UpdateWeeklyReport(c context.Context, report UpdateWeeklyReportRequest, currentUserId primitive.ObjectID, isReportee bool) (WeeklyReport, error) {
-	// Find current user by ID
-	var currentUser user.User
-	err := r.userCollection.FindOne(c, bson.M{"_id": currentUserId}).Decode(&currentUser)
+// GetSkipLevelWeeklyReports returns the reports of everyone below the current user in the
+// reporting lines, or of only reporteeID when it is given. Reports written to someone other than
+// the current user keep only the items their reportee shared with skip-level managers.
+func (r *repositoryImpl) GetSkipLevelWeeklyReports(c context.Context, currentUserId primitive.ObjectID, reporteeID *primitive.ObjectID) ([]WeeklyReport, error) {
+	reporteeIDs, err := r.subtree(c, currentUserId)
 	if err != nil {
-		return WeeklyReport{}, err
+		return nil, err
 	}
 
-	// Find the object for reportingTo user
-	var reportingTo user.User
-	err = r.userCollection.FindOne(c, bson.M{"_id": currentUser.ReportsTo}).Decode(&reportingTo)
+	if reporteeID != nil {
+		inSubtree := false
+		for _, id := range reporteeIDs {
+			if id == *reporteeID {
+				inSubtree = true
+				break
+			}
+		}
+		if !inSubtree {
+			return nil, ErrNotInReportingLine
+		}
+		reporteeIDs = []primitive.ObjectID{*reporteeID}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "year", Value: -1},
+		{Key: "week", Value: -1},
+	})
+
+	filter := bson.M{"reportee": bson.M{"$in": reporteeIDs}}
+	cursor, err := r.collection.Find(c, tenant.Filter(c, filter), findOptions)
 	if err != nil {
-		return WeeklyReport{}, err
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	reports := []WeeklyReport{}
+	if err = cursor.All(c, &reports); err != nil {
+		return nil, err
+	}
+
+	for i, report := range reports {
+		if report.ReportingTo != currentUserId {
+			reports[i] = RedactForSkipLevel(report)
+		}
 	}
 
+	return reports, nil
+}
+
+func (r *repositoryImpl) UpdateWeeklyReport(c context.Context, report UpdateWeeklyReportRequest, currentUserId primitive.ObjectID, isReportee bool) (WeeklyReport, error) {
 	var reportObj WeeklyReport
-	err = r.collection.FindOne(c, bson.M{"_id": report.ID}).Decode(&reportObj)
+	err := r.collection.FindOne(c, tenant.Filter(c, bson.M{"_id": report.ID})).Decode(&reportObj)
 	if err != nil {
 		return WeeklyReport{}, err
 	}
 
+	// Only the reportee decides which items skip-level managers see.
+	if !isReportee {
+		KeepReporteeVisibility(&report, reportObj)
+	}
+
+	// The report stays attributed to the manager the reportee had when they wrote it, even if
+	// they have changed manager since.
+	reporteeID, reportingToID := reportObj.Reportee, reportObj.ReportingTo
+
 	var filter bson.M
 	if isReportee {
 		filter = bson.M{
@@ -136,8 +185,8 @@ func (r *repositoryImpl) UpdateWeeklyReport(c context.Context, report UpdateWeek
 
 	updatedReport := WeeklyReport{
 		ID:              report.ID,
-		Reportee:        currentUserId,
-		ReportingTo:     reportingTo.ID,
+		Reportee:        reporteeID,
+		ReportingTo:     reportingToID,
 		Week:            report.Week,
 		Year:            report.Year,
 		WellbeingScores: report.WellbeingScores,
@@ -152,7 +201,7 @@ func (r *repositoryImpl) UpdateWeeklyReport(c context.Context, report UpdateWeek
 		"$set": updatedReport,
 	}
 
-	result, err := r.collection.UpdateOne(c, filter, update)
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
 	if err != nil {
 		return WeeklyReport{}, err
 	}
@@ -181,7 +230,7 @@ func (r *repositoryImpl) GetWeeklyReportByWeekAndYear(c context.Context, week in
 	}
 
 	var report WeeklyReport
-	err := r.collection.FindOne(c, filter).Decode(&report)
+	err := r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&report)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return WeeklyReport{}, err
@@ -191,3 +240,65 @@ func (r *repositoryImpl) GetWeeklyReportByWeekAndYear(c context.Context, week in
 
 	return report, nil
 }
+
+// manager returns the user the reportee reports to, or ErrNoManager when they have none.
+func (r *repositoryImpl) manager(c context.Context, reportee user.User) (user.User, error) {
+	if reportee.ReportsTo == nil {
+		return user.User{}, ErrNoManager
+	}
+
+	var reportingTo user.User
+	err := r.userCollection.FindOne(c, tenant.Filter(c, bson.M{"_id": *reportee.ReportsTo})).Decode(&reportingTo)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return user.User{}, ErrNoManager
+		}
+		return user.User{}, err
+	}
+
+	return reportingTo, nil
+}
+
+// subtree returns the IDs of everyone who reports to the manager directly or indirectly. The
+// $graphLookup stops at users it has seen before, so a cycle in the data cannot make it run forever.
+func (r *repositoryImpl) subtree(c context.Context, managerID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: tenant.Filter(c, bson.M{"_id": managerID})}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    db.COLLECTION_USER,
+			"startWith":               "$_id",
+			"connectFromField":        "_id",
+			"connectToField":          "reportsTo",
+			"as":                      "reportees",
+			"restrictSearchWithMatch": tenant.Filter(c, bson.M{}),
+		}}},
+		{{Key: "$project", Value: bson.M{"reportees._id": 1}}},
+	}
+
+	cursor, err := r.userCollection.Aggregate(c, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	var results []struct {
+		Reportees []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		} `bson:"reportees"`
+	}
+	if err := cursor.All(c, &results); err != nil {
+		return nil, err
+	}
+
+	reporteeIDs := []primitive.ObjectID{}
+	if len(results) == 0 {
+		return reporteeIDs, nil
+	}
+	for _, reportee := range results[0].Reportees {
+		if reportee.ID != managerID {
+			reporteeIDs = append(reporteeIDs, reportee.ID)
+		}
+	}
+
+	return reporteeIDs, nil
+}
@@ -20,3 +20,31 @@ func FilterEmptyLabels[T any](items []T, getLabel func(T) string) []T {
 	}
 	return filteredItems
 }
+
+// Helper function to keep only the items shared with skip-level managers
+func FilterSkipLevelVisible[T any](items []T, isVisible func(T) bool) []T {
+	filteredItems := []T{}
+	for _, item := range items {
+		if isVisible(item) {
+			filteredItems = append(filteredItems, item)
+		}
+	}
+	return filteredItems
+}
+
+// Helper function to carry the skip-level visibility of stored items over to the items of an
+// update, matching them by label. Items without a stored match are hidden.
+func KeepSkipLevelVisibility[T any](items []T, stored []T, getLabel func(T) string, isVisible func(T) bool, setVisible func(T, bool) T) []T {
+	visible := make(map[string]bool, len(stored))
+	for _, item := range stored {
+		if isVisible(item) {
+			visible[getLabel(item)] = true
+		}
+	}
+
+	keptItems := make([]T, len(items))
+	for i, item := range items {
+		keptItems[i] = setVisible(item, visible[getLabel(item)])
+	}
+	return keptItems
+}
@@ -0,0 +1,21 @@
+package organisation
+
+import "one-to-one/pkg/utils"
+
+func ConvertOrganisationToOrganisationResponse(org Organisation) OrganisationResponse {
+	var defaultManagerID *string
+	if org.DefaultManagerID != nil {
+		defaultManagerID = utils.StringPtr(org.DefaultManagerID.Hex())
+	}
+
+	return OrganisationResponse{
+		ID:               org.ID.Hex(),
+		Name:             org.Name,
+		Slug:             org.Slug,
+		DefaultManagerID: defaultManagerID,
+		RequireMFA:       org.RequireMFA,
+		AllowSignup:      org.SignupAllowed(),
+		CreatedAt:        org.CreatedAt.Time(),
+		UpdatedAt:        org.UpdatedAt.Time(),
+	}
+}
@@ -0,0 +1,95 @@
+package organisation
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrganisationHandler struct {
+	Repo OrganisationRepository
+}
+
+func NewOrganisationHandler(repo OrganisationRepository) *OrganisationHandler {
+	return &OrganisationHandler{Repo: repo}
+}
+
+// @Summary Get the organisation
+// @Description Get the name and settings of the organisation
+// @Tags organisation
+// @Accept json
+// @Produce json
+// @Success 200 {object} OrganisationResponse "Organisation retrieved successfully"
+// @Failure 404 {object} map[string]interface{} "The organisation has not been set up"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organisation [get]
+func (h *OrganisationHandler) GetOrganisation(c *gin.Context) {
+	org, err := h.Repo.GetOrganisation(c.Request.Context())
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "The organisation has not been set up", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved organisation successfully", ConvertOrganisationToOrganisationResponse(*org))
+}
+
+// @Summary Update the organisation
+// @Description Rename the organisation, set the default manager new users report to, whether every member has to use two-factor authentication and whether anyone may sign up. Without a default manager, new users start out unassigned.
+// @Tags organisation
+// @Accept json
+// @Produce json
+// @Param organisation body UpdateOrganisationRequest true "New name, default manager, two-factor requirement and sign-up setting"
+// @Success 200 {object} OrganisationResponse "Organisation updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, or unknown default manager"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "The organisation has not been set up"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /organisation [put]
+func (h *OrganisationHandler) UpdateOrganisation(c *gin.Context) {
+	var reqPayload UpdateOrganisationRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var defaultManagerID *primitive.ObjectID
+	if reqPayload.DefaultManagerID != nil {
+		id, err := primitive.ObjectIDFromHex(*reqPayload.DefaultManagerID)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, "Invalid default manager ID", nil)
+			return
+		}
+		defaultManagerID = &id
+	}
+
+	org, err := h.Repo.GetOrganisation(c.Request.Context())
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "The organisation has not been set up", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	org, err = h.Repo.UpdateOrganisation(c.Request.Context(), org.ID, reqPayload.Name, defaultManagerID, reqPayload.RequireMFA, reqPayload.AllowSignup)
+	if err != nil {
+		if err == ErrUnknownManager {
+			api.Error(c, http.StatusBadRequest, "The default manager does not exist or is deactivated", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Updated organisation successfully", ConvertOrganisationToOrganisationResponse(*org))
+}
@@ -0,0 +1,73 @@
+package organisation
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/config"
+)
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ CREATE OBJECTS -----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+type UpdateOrganisationRequest struct {
+	Name             string  `json:"name" binding:"required,max=100"`
+	DefaultManagerID *string `json:"defaultManagerId"` // new users stay unassigned when null
+	RequireMFA       *bool   `json:"requireMFA"`       // left unchanged when omitted
+	AllowSignup      *bool   `json:"allowSignup"`      // left unchanged when omitted
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+type OrganisationResponse struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Slug             string    `json:"slug"`
+	DefaultManagerID *string   `json:"defaultManagerId"`
+	RequireMFA       bool      `json:"requireMFA"`
+	AllowSignup      bool      `json:"allowSignup"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ MONGO OBJECTS ------------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// Organisation is a tenant and holds its company-wide settings. Slug names it in the Tenant
+// header. DefaultManagerID is the manager self sign-ups and single sign-on users report to until
+// someone else is assigned, nil to leave them unassigned. RequireMFA makes every member enrol in
+// two-factor authentication, see MFARequired. AllowSignup lets anyone create an account through
+// the public sign-up, see SignupAllowed. Bootstrap marks the organisation created by the
+// first-run setup, which a unique index allows only once.
+type Organisation struct {
+	ID               primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Name             string              `json:"name" bson:"name"`
+	Slug             string              `json:"slug" bson:"slug,omitempty"`
+	DefaultManagerID *primitive.ObjectID `json:"defaultManagerId,omitempty" bson:"defaultManagerId,omitempty"`
+	RequireMFA       bool                `json:"requireMFA" bson:"requireMFA,omitempty"`
+	AllowSignup      *bool               `json:"allowSignup,omitempty" bson:"allowSignup,omitempty"`
+	Bootstrap        bool                `json:"-" bson:"bootstrap,omitempty"`
+	CreatedAt        primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt        primitive.DateTime  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// MFARequired reports whether members of the organisation have to use two-factor
+// authentication, because the organisation requires it or REQUIRE_MFA enforces it in every
+// organisation of the deployment.
+func (o Organisation) MFARequired() bool {
+	return o.RequireMFA || config.AppConfig().Auth.RequireMFA
+}
+
+// SignupAllowed reports whether anyone may create an account in the organisation through the
+// public sign-up. Unless changed, only the bootstrap organisation allows it, the others take
+// new members through invitations, SCIM or the directory sync.
+func (o Organisation) SignupAllowed() bool {
+	if o.AllowSignup != nil {
+		return *o.AllowSignup
+	}
+	return o.Bootstrap
+}
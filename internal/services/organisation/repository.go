@@ -0,0 +1,220 @@
+package organisation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"one-to-one/internal/db"
+	"one-to-one/internal/tenant"
+)
+
+var (
+	ErrAlreadySetUp   = errors.New("the organisation has already been set up")
+	ErrSlugTaken      = errors.New("an organisation with this slug already exists")
+	ErrUnknownManager = errors.New("the default manager does not exist or is deactivated")
+)
+
+type OrganisationRepository interface {
+	CreateOrganisation(c context.Context, org Organisation) error
+	GetOrganisation(c context.Context) (*Organisation, error)
+	GetOrganisationByID(c context.Context, id primitive.ObjectID) (*Organisation, error)
+	GetOrganisationBySlug(c context.Context, slug string) (*Organisation, error)
+	GetBootstrapOrganisation(c context.Context) (*Organisation, error)
+	MFARequired(c context.Context) (bool, error)
+	SignupAllowed(c context.Context) (bool, error)
+	UpdateOrganisation(c context.Context, id primitive.ObjectID, name string, defaultManagerID *primitive.ObjectID, requireMFA *bool, allowSignup *bool) (*Organisation, error)
+	DeleteOrganisation(c context.Context, id primitive.ObjectID) error
+	AdoptUnscopedData(c context.Context, id primitive.ObjectID) error
+}
+
+type repositoryImpl struct {
+	collection     *mongo.Collection
+	userCollection *mongo.Collection
+	database       *mongo.Database
+}
+
+// scopedCollections hold the documents that belong to an organisation.
+var scopedCollections = []string{db.COLLECTION_USER, db.COLLECTION_WEEKLY_REPORT, db.COLLECTION_INVITATION, db.COLLECTION_AUDIT_LOG, db.COLLECTION_REPORTING_LINE, db.COLLECTION_REPORTING_LINE_REQUEST}
+
+var indexesOnce sync.Once
+
+func NewOrganisationRepository() OrganisationRepository {
+	r := &repositoryImpl{
+		collection:     db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_ORGANISATION),
+		userCollection: db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_USER),
+		database:       db.Client.Database(db.DATABASE_NAME),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys: bson.D{{Key: "bootstrap", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"bootstrap": true}),
+			},
+			{
+				Keys: bson.D{{Key: "slug", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+			},
+		})
+		if err != nil {
+			log.Println("Failed to create organisation indexes: ", err)
+		}
+	})
+
+	return r
+}
+
+// CreateOrganisation stores a new organisation. Creating a second bootstrap organisation fails
+// with ErrAlreadySetUp, even when two setups race, and reusing a slug with ErrSlugTaken.
+func (r *repositoryImpl) CreateOrganisation(c context.Context, org Organisation) error {
+	_, err := r.collection.InsertOne(c, org)
+	if mongo.IsDuplicateKeyError(err) {
+		if org.Bootstrap {
+			if _, getErr := r.GetBootstrapOrganisation(c); getErr == nil {
+				return ErrAlreadySetUp
+			}
+		}
+		return ErrSlugTaken
+	}
+	return err
+}
+
+// GetOrganisation returns the organisation the context is scoped to.
+func (r *repositoryImpl) GetOrganisation(c context.Context) (*Organisation, error) {
+	id, ok := tenant.OrganisationID(c)
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return r.GetOrganisationByID(c, id)
+}
+
+func (r *repositoryImpl) GetOrganisationByID(c context.Context, id primitive.ObjectID) (*Organisation, error) {
+	return r.findOne(c, bson.M{"_id": id})
+}
+
+func (r *repositoryImpl) GetOrganisationBySlug(c context.Context, slug string) (*Organisation, error) {
+	return r.findOne(c, bson.M{"slug": slug})
+}
+
+// GetBootstrapOrganisation returns the organisation created by the first-run setup, which
+// requests that name no tenant belong to.
+func (r *repositoryImpl) GetBootstrapOrganisation(c context.Context) (*Organisation, error) {
+	return r.findOne(c, bson.M{"bootstrap": true})
+}
+
+// MFARequired reports whether the organisation the context is scoped to requires two-factor
+// authentication. Before the first-run setup, only REQUIRE_MFA applies.
+func (r *repositoryImpl) MFARequired(c context.Context) (bool, error) {
+	org, err := r.GetOrganisation(c)
+	if err == mongo.ErrNoDocuments {
+		return Organisation{}.MFARequired(), nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return org.MFARequired(), nil
+}
+
+// SignupAllowed reports whether the organisation the context is scoped to accepts public
+// sign-ups. Before the first-run setup there is no organisation to protect and they are allowed.
+func (r *repositoryImpl) SignupAllowed(c context.Context) (bool, error) {
+	org, err := r.GetOrganisation(c)
+	if err == mongo.ErrNoDocuments {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return org.SignupAllowed(), nil
+}
+
+func (r *repositoryImpl) findOne(c context.Context, filter bson.M) (*Organisation, error) {
+	var org Organisation
+	err := r.collection.FindOne(c, filter).Decode(&org)
+	if err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// UpdateOrganisation renames the organisation and sets its default manager, who has to be an
+// active user of the organisation. A nil requireMFA or allowSignup leaves that setting unchanged.
+func (r *repositoryImpl) UpdateOrganisation(c context.Context, id primitive.ObjectID, name string, defaultManagerID *primitive.ObjectID, requireMFA *bool, allowSignup *bool) (*Organisation, error) {
+	update := bson.M{"$set": bson.M{
+		"name":      name,
+		"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+	}}
+	if requireMFA != nil {
+		update["$set"].(bson.M)["requireMFA"] = *requireMFA
+	}
+	if allowSignup != nil {
+		update["$set"].(bson.M)["allowSignup"] = *allowSignup
+	}
+
+	if defaultManagerID != nil {
+		filter := bson.M{"_id": *defaultManagerID, tenant.Field: id, "deactivatedAt": bson.M{"$exists": false}}
+		count, err := r.userCollection.CountDocuments(c, filter, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrUnknownManager
+		}
+		update["$set"].(bson.M)["defaultManagerId"] = *defaultManagerID
+	} else {
+		update["$unset"] = bson.M{"defaultManagerId": ""}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var org Organisation
+	err := r.collection.FindOneAndUpdate(c, bson.M{"_id": id}, update, opts).Decode(&org)
+	if err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// DeleteOrganisation removes an organisation whose setup failed, so that it can be run again.
+// The documents it adopted are returned to no organisation.
+func (r *repositoryImpl) DeleteOrganisation(c context.Context, id primitive.ObjectID) error {
+	filter := bson.M{tenant.Field: id}
+	update := bson.M{"$unset": bson.M{tenant.Field: ""}}
+
+	for _, name := range scopedCollections {
+		if _, err := r.database.Collection(name).UpdateMany(c, filter, update); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.collection.DeleteOne(c, bson.M{"_id": id})
+	return err
+}
+
+// AdoptUnscopedData moves the documents stored before organisations were introduced into the
+// organisation.
+func (r *repositoryImpl) AdoptUnscopedData(c context.Context, id primitive.ObjectID) error {
+	filter := bson.M{tenant.Field: bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{tenant.Field: id}}
+
+	for _, name := range scopedCollections {
+		if _, err := r.database.Collection(name).UpdateMany(c, filter, update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package reportingline
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+func ConvertChangeRequestToResponse(request ChangeRequest) ChangeRequestResponse {
+	response := ChangeRequestResponse{
+		ID:                request.ID.Hex(),
+		UserID:            request.UserID.Hex(),
+		ManagerID:         hexOrNil(request.ManagerID),
+		PreviousManagerID: hexOrNil(request.PreviousManagerID),
+		RequestedBy:       request.RequestedBy.Hex(),
+		ApproverID:        request.ApproverID.Hex(),
+		Status:            request.Status,
+		ResolvedBy:        hexOrNil(request.ResolvedBy),
+		CreatedAt:         request.CreatedAt.Time(),
+	}
+
+	if request.ResolvedAt != nil {
+		resolvedAt := request.ResolvedAt.Time()
+		response.ResolvedAt = &resolvedAt
+	}
+
+	return response
+}
+
+func ConvertChangeRequestsToResponses(requests []ChangeRequest) []ChangeRequestResponse {
+	responses := make([]ChangeRequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = ConvertChangeRequestToResponse(request)
+	}
+	return responses
+}
+
+func hexOrNil(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
+	}
+	hex := id.Hex()
+	return &hex
+}
@@ -0,0 +1,471 @@
+package reportingline
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/pusher"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/user"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReportingLineHandler struct {
+	Repo      ReportingLineRepository
+	UserRepo  user.UserRepository
+	AuditRepo audit.AuditRepository
+}
+
+func NewReportingLineHandler(repo ReportingLineRepository, userRepo user.UserRepository, auditRepo audit.AuditRepository) *ReportingLineHandler {
+	return &ReportingLineHandler{Repo: repo, UserRepo: userRepo, AuditRepo: auditRepo}
+}
+
+// @Summary Add reportee
+// @Description Ask a user to report to the current user. The change is made once the user, or an admin or HR, accepts it.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param reportee body user.AddReporteeRequest true "Reportee object to be added"
+// @Success 202 {object} ChangeRequestResponse "Change requested successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 404 {object} map[string]interface{} "Reportee not found"
+// @Failure 409 {object} map[string]interface{} "The user already reports to the current user, would be their own manager or the change has already been requested"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/reportee/add [post]
+func (h *ReportingLineHandler) RequestReportee(c *gin.Context) {
+	currentUser, err := h.UserRepo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var reqPayload user.AddReporteeRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	reportee, err := h.UserRepo.GetUserByEmail(c.Request.Context(), reqPayload.ReporteeEmail)
+	if err != nil {
+		api.Error(c, http.StatusNotFound, "Reportee not found", nil)
+		return
+	}
+
+	h.request(c, *currentUser, ChangeRequest{
+		UserID:            reportee.ID,
+		ManagerID:         &currentUser.ID,
+		PreviousManagerID: reportee.ReportsTo,
+		RequestedBy:       currentUser.ID,
+		ApproverID:        reportee.ID,
+	}, reportee.Email)
+}
+
+// @Summary Remove reportee
+// @Description Ask a user who reports to the current user to report to nobody. The change is made once the user, or an admin or HR, accepts it.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param reportee body user.RemoveReporteeRequest true "Reportee object to be removed"
+// @Success 202 {object} ChangeRequestResponse "Change requested successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 404 {object} map[string]interface{} "Reportee not found"
+// @Failure 409 {object} map[string]interface{} "The change has already been requested"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/reportee/remove [post]
+func (h *ReportingLineHandler) RequestReporteeRemoval(c *gin.Context) {
+	currentUser, err := h.UserRepo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var reqPayload user.RemoveReporteeRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	reportee, err := h.UserRepo.GetUserByEmail(c.Request.Context(), reqPayload.ReporteeEmail)
+	if err != nil || reportee.ReportsTo == nil || *reportee.ReportsTo != currentUser.ID {
+		api.Error(c, http.StatusNotFound, "Reportee not found", nil)
+		return
+	}
+
+	h.request(c, *currentUser, ChangeRequest{
+		UserID:            reportee.ID,
+		PreviousManagerID: reportee.ReportsTo,
+		RequestedBy:       currentUser.ID,
+		ApproverID:        reportee.ID,
+	}, reportee.Email)
+}
+
+// @Summary Add reports to user
+// @Description Ask another user to become the manager of the current user. The change is made once that user, or an admin or HR, accepts it.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param report body user.AddReportsToRequest true "Report object to be added"
+// @Success 202 {object} ChangeRequestResponse "Change requested successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "The current user already reports to the user, would be their own manager or the change has already been requested"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/reports-to/add [post]
+func (h *ReportingLineHandler) RequestManager(c *gin.Context) {
+	currentUser, err := h.UserRepo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var reqPayload user.AddReportsToRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	manager, err := h.UserRepo.GetUserByEmail(c.Request.Context(), reqPayload.ReportsToEmail)
+	if err != nil {
+		api.Error(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	h.request(c, *currentUser, ChangeRequest{
+		UserID:            currentUser.ID,
+		ManagerID:         &manager.ID,
+		PreviousManagerID: currentUser.ReportsTo,
+		RequestedBy:       currentUser.ID,
+		ApproverID:        manager.ID,
+	}, currentUser.Email)
+}
+
+// request stores a change request, records it in the audit log and lets the approver know.
+func (h *ReportingLineHandler) request(c *gin.Context, requester user.User, request ChangeRequest, subjectEmail string) {
+	if request.ManagerID != nil && *request.ManagerID == request.UserID {
+		api.Error(c, http.StatusConflict, user.ErrSelfManager.Error(), nil)
+		return
+	}
+	if request.ManagerID != nil && request.PreviousManagerID != nil && *request.ManagerID == *request.PreviousManagerID {
+		api.Error(c, http.StatusConflict, "The user already reports to this manager", nil)
+		return
+	}
+
+	created, err := h.Repo.CreateRequest(c.Request.Context(), request)
+	if err != nil {
+		if err == ErrDuplicateRequest {
+			api.Error(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if err := h.record(c, audit.ActionReportingLineRequest, requester.ID, requester.Email, created, subjectEmail); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	notify(created, created.ApproverID)
+
+	api.Success(c, http.StatusAccepted, "Requested reporting line change successfully", ConvertChangeRequestToResponse(created))
+}
+
+// @Summary List reporting line change requests
+// @Description List the change requests the current user made, has to answer or is the subject of, the most recent first
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Success 200 {array} ChangeRequestResponse "Change requests retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /reporting-line/requests [get]
+func (h *ReportingLineHandler) GetRequests(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	requests, err := h.Repo.GetRequestsForUser(c.Request.Context(), userID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved change requests successfully", ConvertChangeRequestsToResponses(requests))
+}
+
+// @Summary List pending reporting line change requests
+// @Description List every change request of the organisation waiting for an answer. Admin and HR only.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Success 200 {array} ChangeRequestResponse "Change requests retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /reporting-line/requests/pending [get]
+func (h *ReportingLineHandler) GetPendingRequests(c *gin.Context) {
+	requests, err := h.Repo.GetPendingRequests(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved change requests successfully", ConvertChangeRequestsToResponses(requests))
+}
+
+// @Summary Accept a reporting line change request
+// @Description Accept a change request and make the change. Only the approver of the request, an admin or HR can accept it.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Success 200 {object} ChangeRequestResponse "Change request accepted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid change request ID"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Change request not found"
+// @Failure 409 {object} map[string]interface{} "The request was already answered, is out of date, or would make a user their own manager or close a cycle"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /reporting-line/requests/{id}/accept [post]
+func (h *ReportingLineHandler) AcceptRequest(c *gin.Context) {
+	request, actorID, ok := h.pendingRequest(c, true)
+	if !ok {
+		return
+	}
+
+	account, err := h.UserRepo.GetUserByID(c.Request.Context(), request.UserID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if !sameManager(account.ReportsTo, request.PreviousManagerID) {
+		api.Error(c, http.StatusConflict, "The user has changed manager since the request was made", nil)
+		return
+	}
+
+	if !h.resolve(c, request, StatusAccepted, actorID) {
+		return
+	}
+
+	// The change is audited before it is made, so that no reporting line changes unrecorded.
+	request = answered(request, StatusAccepted, actorID)
+	if err := h.record(c, audit.ActionReportingLineAccept, actorID, c.GetString("email"), request, account.Email); err != nil {
+		h.reopen(c, request, err)
+		return
+	}
+
+	if err := h.UserRepo.SetManager(c.Request.Context(), request.UserID, request.ManagerID); err != nil {
+		h.reopen(c, request, err)
+		return
+	}
+
+	h.notifyAnswer(c, request)
+}
+
+// @Summary Decline a reporting line change request
+// @Description Decline a change request. Only the approver of the request, an admin or HR can decline it.
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Success 200 {object} ChangeRequestResponse "Change request declined successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid change request ID"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Change request not found"
+// @Failure 409 {object} map[string]interface{} "The request was already answered"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /reporting-line/requests/{id}/decline [post]
+func (h *ReportingLineHandler) DeclineRequest(c *gin.Context) {
+	h.close(c, StatusDeclined, true)
+}
+
+// @Summary Cancel a reporting line change request
+// @Description Withdraw a change request the current user made
+// @Tags reporting-lines
+// @Accept json
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Success 200 {object} ChangeRequestResponse "Change request cancelled successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid change request ID"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Change request not found"
+// @Failure 409 {object} map[string]interface{} "The request was already answered"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /reporting-line/requests/{id}/cancel [post]
+func (h *ReportingLineHandler) CancelRequest(c *gin.Context) {
+	h.close(c, StatusCancelled, false)
+}
+
+// close declines or cancels a request without changing any reporting line.
+func (h *ReportingLineHandler) close(c *gin.Context, status string, byApprover bool) {
+	request, actorID, ok := h.pendingRequest(c, byApprover)
+	if !ok {
+		return
+	}
+
+	if !h.resolve(c, request, status, actorID) {
+		return
+	}
+
+	account, err := h.UserRepo.GetUserByID(c.Request.Context(), request.UserID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	subjectEmail := ""
+	if account != nil {
+		subjectEmail = account.Email
+	}
+
+	action := audit.ActionReportingLineDecline
+	if status == StatusCancelled {
+		action = audit.ActionReportingLineCancel
+	}
+	request = answered(request, status, actorID)
+	if err := h.record(c, action, actorID, c.GetString("email"), request, subjectEmail); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	h.notifyAnswer(c, request)
+}
+
+// pendingRequest loads the request named in the path and checks the current user may answer
+// it: its approver, or an admin or HR, when byApprover is set, and its requester otherwise.
+func (h *ReportingLineHandler) pendingRequest(c *gin.Context, byApprover bool) (ChangeRequest, primitive.ObjectID, bool) {
+	actorID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return ChangeRequest{}, primitive.NilObjectID, false
+	}
+
+	requestID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid change request ID", nil)
+		return ChangeRequest{}, primitive.NilObjectID, false
+	}
+
+	request, err := h.Repo.GetRequestByID(c.Request.Context(), requestID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "Change request not found", nil)
+			return ChangeRequest{}, primitive.NilObjectID, false
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return ChangeRequest{}, primitive.NilObjectID, false
+	}
+
+	manager := auth.HasPermission(c.GetStringSlice("roles"), auth.PermissionManageReportingLines)
+	allowed := request.RequestedBy == actorID
+	if byApprover {
+		allowed = request.ApproverID == actorID || manager
+	}
+	if !allowed {
+		// Hide requests the user has nothing to do with.
+		if !request.Involves(actorID) && !manager {
+			api.Error(c, http.StatusNotFound, "Change request not found", nil)
+			return ChangeRequest{}, primitive.NilObjectID, false
+		}
+		api.Error(c, http.StatusForbidden, "You do not have permission to perform this action", nil)
+		return ChangeRequest{}, primitive.NilObjectID, false
+	}
+
+	if request.Status != StatusPending {
+		api.Error(c, http.StatusConflict, "The change request has already been answered", nil)
+		return ChangeRequest{}, primitive.NilObjectID, false
+	}
+
+	return *request, actorID, true
+}
+
+// resolve moves the request out of pending, failing when someone else answered it first.
+func (h *ReportingLineHandler) resolve(c *gin.Context, request ChangeRequest, status string, actorID primitive.ObjectID) bool {
+	resolved, err := h.Repo.ResolveRequest(c.Request.Context(), request.ID, status, actorID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return false
+	}
+	if !resolved {
+		api.Error(c, http.StatusConflict, "The change request has already been answered", nil)
+		return false
+	}
+	return true
+}
+
+// reopen puts an accepted request back to pending when the change could not be made, so that it
+// can be answered again, and answers with the error.
+func (h *ReportingLineHandler) reopen(c *gin.Context, request ChangeRequest, err error) {
+	if reopenErr := h.Repo.ReopenRequest(c.Request.Context(), request.ID); reopenErr != nil {
+		api.Error(c, http.StatusInternalServerError, reopenErr.Error(), nil)
+		return
+	}
+	if user.IsReportingLineError(err) {
+		api.Error(c, http.StatusConflict, err.Error(), nil)
+		return
+	}
+	api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+}
+
+// answered returns the request as stored once resolved.
+func answered(request ChangeRequest, status string, actorID primitive.ObjectID) ChangeRequest {
+	request.Status = status
+	request.ResolvedBy = &actorID
+	return request
+}
+
+// notifyAnswer lets the requester and the user whose reporting line it is know about the answer
+// to a request, and answers with the request.
+func (h *ReportingLineHandler) notifyAnswer(c *gin.Context, request ChangeRequest) {
+	notify(request, request.RequestedBy)
+	if request.UserID != request.RequestedBy {
+		notify(request, request.UserID)
+	}
+	if request.Status == StatusCancelled {
+		notify(request, request.ApproverID)
+	}
+
+	api.Success(c, http.StatusOK, "Answered change request successfully", ConvertChangeRequestToResponse(request))
+}
+
+func (h *ReportingLineHandler) record(c *gin.Context, action string, actorID primitive.ObjectID, actorEmail string, request ChangeRequest, subjectEmail string) error {
+	details := map[string]interface{}{"requestId": request.ID.Hex()}
+	if request.ManagerID != nil {
+		details["managerId"] = request.ManagerID.Hex()
+	}
+	if request.PreviousManagerID != nil {
+		details["previousManagerId"] = request.PreviousManagerID.Hex()
+	}
+
+	return h.AuditRepo.Record(c.Request.Context(), audit.Entry{
+		Action:       action,
+		ActorID:      actorID,
+		ActorEmail:   actorEmail,
+		SubjectID:    &request.UserID,
+		SubjectEmail: subjectEmail,
+		IP:           c.ClientIP(),
+		Details:      details,
+	})
+}
+
+func notify(request ChangeRequest, recipientID primitive.ObjectID) {
+	pusher.Notify(pusher.UserChannel(recipientID.Hex()), pusher.ReportingLineRequest, Notification{
+		RequestID: request.ID.Hex(),
+		Status:    request.Status,
+	})
+}
+
+func sameManager(a *primitive.ObjectID, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
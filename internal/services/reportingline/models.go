@@ -0,0 +1,65 @@
+package reportingline
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of a change request.
+const (
+	StatusPending   = "pending"
+	StatusAccepted  = "accepted"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+)
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+type ChangeRequestResponse struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"userId"`
+	ManagerID         *string    `json:"managerId,omitempty"`
+	PreviousManagerID *string    `json:"previousManagerId,omitempty"`
+	RequestedBy       string     `json:"requestedBy"`
+	ApproverID        string     `json:"approverId"`
+	Status            string     `json:"status"`
+	ResolvedBy        *string    `json:"resolvedBy,omitempty"`
+	ResolvedAt        *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+// Notification is the payload of the Pusher events about a change request.
+type Notification struct {
+	RequestID string `json:"requestId"`
+	Status    string `json:"status"`
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ MONGO OBJECTS ------------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+// ChangeRequest asks for the user to report to ManagerID, or to nobody when it is nil. Nothing
+// changes until the approver, the other party to the new or ended reporting line, or an admin or
+// HR accepts it. PreviousManagerID is the manager the user had when the request was made.
+type ChangeRequest struct {
+	ID                primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID    primitive.ObjectID  `json:"-" bson:"organisationId,omitempty"`
+	UserID            primitive.ObjectID  `json:"userId" bson:"userId"`
+	ManagerID         *primitive.ObjectID `json:"managerId,omitempty" bson:"managerId,omitempty"`
+	PreviousManagerID *primitive.ObjectID `json:"previousManagerId,omitempty" bson:"previousManagerId,omitempty"`
+	RequestedBy       primitive.ObjectID  `json:"requestedBy" bson:"requestedBy"`
+	ApproverID        primitive.ObjectID  `json:"approverId" bson:"approverId"`
+	Status            string              `json:"status" bson:"status"`
+	ResolvedBy        *primitive.ObjectID `json:"resolvedBy,omitempty" bson:"resolvedBy,omitempty"`
+	ResolvedAt        *primitive.DateTime `json:"resolvedAt,omitempty" bson:"resolvedAt,omitempty"`
+	CreatedAt         primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+}
+
+// Involves reports whether the user is a party to the request.
+func (r ChangeRequest) Involves(userID primitive.ObjectID) bool {
+	return r.UserID == userID || r.RequestedBy == userID || r.ApproverID == userID ||
+		(r.ManagerID != nil && *r.ManagerID == userID) ||
+		(r.PreviousManagerID != nil && *r.PreviousManagerID == userID)
+}
@@ -0,0 +1,162 @@
+package reportingline
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"one-to-one/internal/db"
+	"one-to-one/internal/tenant"
+)
+
+// ErrDuplicateRequest is returned when the same change is already waiting for an answer.
+var ErrDuplicateRequest = errors.New("the same change has already been requested")
+
+type ReportingLineRepository interface {
+	CreateRequest(c context.Context, request ChangeRequest) (ChangeRequest, error)
+	GetRequestByID(c context.Context, id primitive.ObjectID) (*ChangeRequest, error)
+	GetRequestsForUser(c context.Context, userID primitive.ObjectID) ([]ChangeRequest, error)
+	GetPendingRequests(c context.Context) ([]ChangeRequest, error)
+	ResolveRequest(c context.Context, id primitive.ObjectID, status string, resolvedBy primitive.ObjectID) (bool, error)
+	ReopenRequest(c context.Context, id primitive.ObjectID) error
+}
+
+type repositoryImpl struct {
+	collection *mongo.Collection
+}
+
+var indexesOnce sync.Once
+
+func NewReportingLineRepository() ReportingLineRepository {
+	r := &repositoryImpl{
+		collection: db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_REPORTING_LINE_REQUEST),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "approverId", Value: 1}}},
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "requestedBy", Value: 1}}},
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "userId", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create reporting line request indexes: ", err)
+		}
+	})
+
+	return r
+}
+
+// CreateRequest stores a pending request in the organisation of the context.
+func (r *repositoryImpl) CreateRequest(c context.Context, request ChangeRequest) (ChangeRequest, error) {
+	organisationID, err := tenant.Require(c)
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+
+	duplicate := bson.M{
+		"userId":      request.UserID,
+		"managerId":   request.ManagerID,
+		"requestedBy": request.RequestedBy,
+		"status":      StatusPending,
+	}
+	count, err := r.collection.CountDocuments(c, tenant.Filter(c, duplicate))
+	if err != nil {
+		return ChangeRequest{}, err
+	}
+	if count > 0 {
+		return ChangeRequest{}, ErrDuplicateRequest
+	}
+
+	request.ID = primitive.NewObjectID()
+	request.OrganisationID = organisationID
+	request.Status = StatusPending
+	request.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	if _, err := r.collection.InsertOne(c, request); err != nil {
+		return ChangeRequest{}, err
+	}
+
+	return request, nil
+}
+
+func (r *repositoryImpl) GetRequestByID(c context.Context, id primitive.ObjectID) (*ChangeRequest, error) {
+	var request ChangeRequest
+	err := r.collection.FindOne(c, tenant.Filter(c, bson.M{"_id": id})).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// GetRequestsForUser returns the requests the user made, has to answer, or whose reporting
+// line they are on, the most recent first.
+func (r *repositoryImpl) GetRequestsForUser(c context.Context, userID primitive.ObjectID) ([]ChangeRequest, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"userId": userID},
+		bson.M{"requestedBy": userID},
+		bson.M{"approverId": userID},
+	}}
+
+	return r.find(c, filter)
+}
+
+// GetPendingRequests returns every request of the organisation waiting for an answer.
+func (r *repositoryImpl) GetPendingRequests(c context.Context) ([]ChangeRequest, error) {
+	return r.find(c, bson.M{"status": StatusPending})
+}
+
+func (r *repositoryImpl) find(c context.Context, filter bson.M) ([]ChangeRequest, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(c, tenant.Filter(c, filter), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	requests := []ChangeRequest{}
+	if err := cursor.All(c, &requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// ResolveRequest moves a pending request to status. It reports false when the request was
+// resolved in the meantime.
+func (r *repositoryImpl) ResolveRequest(c context.Context, id primitive.ObjectID, status string, resolvedBy primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "status": StatusPending}
+	update := bson.M{"$set": bson.M{
+		"status":     status,
+		"resolvedBy": resolvedBy,
+		"resolvedAt": primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ReopenRequest puts back a request whose acceptance could not be applied.
+func (r *repositoryImpl) ReopenRequest(c context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"status": StatusPending},
+		"$unset": bson.M{"resolvedBy": "", "resolvedAt": ""},
+	}
+
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, bson.M{"_id": id}), update)
+	return err
+}
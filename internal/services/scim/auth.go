@@ -0,0 +1,34 @@
+package scim
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"one-to-one/internal/config"
+)
+
+// RequireToken authenticates SCIM clients with the bearer token configured in SCIM_TOKEN. The
+// SCIM API is disabled while no token is configured.
+func RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configured := config.AppConfig().SCIM.Token
+		if configured == "" {
+			writeError(c, &Error{Status: http.StatusNotFound, Detail: "SCIM provisioning is not configured"})
+			return
+		}
+
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		// Hashing first keeps the comparison constant-time regardless of the token length.
+		expected, presented := sha256.Sum256([]byte(configured)), sha256.Sum256([]byte(token))
+		if !found || subtle.ConstantTimeCompare(expected[:], presented[:]) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			writeError(c, &Error{Status: http.StatusUnauthorized, Detail: "Invalid bearer token"})
+			return
+		}
+
+		c.Next()
+	}
+}
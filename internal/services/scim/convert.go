@@ -0,0 +1,65 @@
+package scim
+
+import (
+	"strings"
+
+	"one-to-one/internal/services/user"
+)
+
+func ConvertUserToUserResource(account user.User) UserResource {
+	active := account.Active()
+	resource := UserResource{
+		Schemas:     []string{SchemaUser, SchemaEnterpriseUser},
+		ID:          account.ID.Hex(),
+		ExternalID:  account.ExternalID,
+		UserName:    account.Email,
+		Name:        &Name{GivenName: account.FirstName, FamilyName: account.LastName},
+		DisplayName: strings.TrimSpace(account.FirstName + " " + account.LastName),
+		Emails:      []Email{{Value: account.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta:        &Meta{ResourceType: "User"},
+	}
+	resource.Name.Formatted = resource.DisplayName
+
+	if account.ReportsTo != nil {
+		resource.Enterprise = &EnterpriseUser{Manager: &Manager{Value: account.ReportsTo.Hex()}}
+	}
+
+	if account.CreatedAt != 0 {
+		created := account.CreatedAt.Time()
+		resource.Meta.Created = &created
+	}
+	if account.UpdatedAt != 0 {
+		lastModified := account.UpdatedAt.Time()
+		resource.Meta.LastModified = &lastModified
+	} else if account.CreatedAt != 0 {
+		resource.Meta.LastModified = resource.Meta.Created
+	}
+
+	return resource
+}
+
+// managerID returns the SCIM id of the manager in resource, or "" when it has none.
+func managerID(resource UserResource) string {
+	if resource.Enterprise == nil || resource.Enterprise.Manager == nil {
+		return ""
+	}
+	return resource.Enterprise.Manager.Value
+}
+
+func serviceProviderConfig() ServiceProviderConfig {
+	return ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          Supported{Supported: true},
+		Bulk:           BulkSupport{Supported: false},
+		Filter:         FilterSupport{Supported: true, MaxResults: maxResults},
+		ChangePassword: Supported{Supported: false},
+		Sort:           Supported{Supported: false},
+		ETag:           Supported{Supported: false},
+		AuthenticationSchemes: []AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "The token configured in SCIM_TOKEN, sent in the Authorization header",
+		}},
+	}
+}
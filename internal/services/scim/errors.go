@@ -0,0 +1,47 @@
+package scim
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error is a failure reported to the SCIM client. ScimType is one of the detail error keywords
+// of RFC 7644, section 3.12.
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func invalidValue(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: detail}
+}
+
+// writeResource answers with a SCIM resource or message.
+func writeResource(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", ContentType)
+	c.JSON(status, body)
+}
+
+// writeError answers with a SCIM error. Errors that are not an *Error are reported as internal
+// server errors without their details.
+func writeError(c *gin.Context, err error) {
+	scimErr, ok := err.(*Error)
+	if !ok {
+		scimErr = &Error{Status: http.StatusInternalServerError, Detail: "An error occurred while processing your request"}
+	}
+
+	writeResource(c, scimErr.Status, ErrorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(scimErr.Status),
+		ScimType: scimErr.ScimType,
+		Detail:   scimErr.Detail,
+	})
+	c.Abort()
+}
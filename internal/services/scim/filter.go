@@ -0,0 +1,66 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/services/user"
+)
+
+// filterPattern matches the only filters supported, a single equality test such as
+// userName eq "jane@example.com". That is what provisioning clients use to look up a user
+// before creating it.
+var filterPattern = regexp.MustCompile(`(?i)^\s*([a-z.]+)\s+eq\s+(.+?)\s*$`)
+
+// parseFilter turns a SCIM filter into a user filter. Attribute names are case-insensitive.
+func parseFilter(filter string) (user.UserFilter, error) {
+	var result user.UserFilter
+	if strings.TrimSpace(filter) == "" {
+		return result, nil
+	}
+
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return result, invalidFilter("Only filters of the form 'attribute eq value' are supported")
+	}
+	attribute, rawValue := strings.ToLower(match[1]), match[2]
+
+	if attribute == "active" {
+		active, err := parseBool(json.RawMessage(rawValue))
+		if err != nil {
+			return result, invalidFilter("active can only be compared with true or false")
+		}
+		result.Active = &active
+		return result, nil
+	}
+
+	var value string
+	if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+		return result, invalidFilter("Expected a quoted string after eq")
+	}
+
+	switch attribute {
+	case "id":
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			// No user has this id.
+			id = primitive.NilObjectID
+		}
+		result.ID = &id
+	case "username", "emails.value":
+		result.Email = value
+	case "externalid":
+		result.ExternalID = value
+	default:
+		return result, invalidFilter("Filtering on " + match[1] + " is not supported")
+	}
+
+	return result, nil
+}
+
+func invalidFilter(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: detail}
+}
@@ -0,0 +1,438 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/user"
+)
+
+// SCIMHandler serves the SCIM 2.0 user provisioning API of RFC 7644. Responses follow the SCIM
+// format rather than the api response envelope, as that is what provisioning clients expect.
+type SCIMHandler struct {
+	UserRepo user.UserRepository
+	AuthRepo auth.AuthRepository
+}
+
+func NewSCIMHandler(userRepo user.UserRepository, authRepo auth.AuthRepository) *SCIMHandler {
+	return &SCIMHandler{UserRepo: userRepo, AuthRepo: authRepo}
+}
+
+// @Summary Get the SCIM service provider configuration
+// @Description Describe the SCIM features supported by this server
+// @Tags scim
+// @Produce json
+// @Success 200 {object} ServiceProviderConfig "Service provider configuration"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Security BearerAuth
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) GetServiceProviderConfig(c *gin.Context) {
+	writeResource(c, http.StatusOK, serviceProviderConfig())
+}
+
+// @Summary List users
+// @Description List users for a SCIM client. The only supported filters are equality tests on id, userName, emails.value, externalId and active.
+// @Tags scim
+// @Produce json
+// @Param filter query string false "Filter, e.g. userName eq \"jane@example.com\""
+// @Param startIndex query int false "1-based index of the first result" default(1)
+// @Param count query int false "Maximum number of results" default(100)
+// @Success 200 {object} ListResponse "Users retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Unsupported filter"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) GetUsers(c *gin.Context) {
+	filter, err := parseFilter(c.Query("filter"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	startIndex, err := strconv.ParseInt(c.DefaultQuery("startIndex", "1"), 10, 64)
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count > maxResults {
+		count = maxResults
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	// A count of 0 only asks for the total, a limit of 0 would ask for every user.
+	limit := count
+	if limit == 0 {
+		limit = 1
+	}
+
+	users, total, err := h.UserRepo.GetUsers(c.Request.Context(), filter, startIndex-1, limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if count == 0 {
+		users = nil
+	}
+
+	resources := make([]UserResource, len(users))
+	for i, account := range users {
+		resources[i] = ConvertUserToUserResource(account)
+	}
+
+	writeResource(c, http.StatusOK, ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// @Summary Get a user
+// @Description Get a user by their SCIM id
+// @Tags scim
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserResource "User retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	account, err := h.findUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeResource(c, http.StatusOK, ConvertUserToUserResource(*account))
+}
+
+// @Summary Create a user
+// @Description Provision a user. The userName is the email address of the account, which is treated as verified. The enterprise manager attribute sets who the user reports to.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param user body UserResource true "User to provision"
+// @Success 201 {object} UserResource "User created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 409 {object} ErrorResponse "A user with this userName already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource UserResource
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeError(c, &Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+		return
+	}
+
+	if err := validateResource(&resource); err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := h.checkUserNameAvailable(c.Request.Context(), resource.UserName); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	var manager *user.User
+	if id := managerID(resource); id != "" {
+		var err error
+		if manager, err = h.findManager(c.Request.Context(), id, primitive.NilObjectID); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	account := user.NewProvisionedUser(resource.UserName, resource.Name.GivenName, resource.Name.FamilyName)
+	now := primitive.NewDateTimeFromTime(time.Now())
+	account.ExternalID = resource.ExternalID
+	account.EmailVerified = true
+	account.EmailVerifiedAt = &now
+	account.CreatedAt = now
+	account.UpdatedAt = now
+	if resource.Active != nil && !*resource.Active {
+		account.DeactivatedAt = &now
+	}
+
+	created, err := h.UserRepo.CreateUser(c.Request.Context(), account)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if manager != nil {
+		if err := h.UserRepo.SetManager(c.Request.Context(), created.ID, &manager.ID); err != nil {
+			writeError(c, err)
+			return
+		}
+		created.ReportsTo = &manager.ID
+	}
+
+	writeResource(c, http.StatusCreated, ConvertUserToUserResource(created))
+}
+
+// @Summary Replace a user
+// @Description Replace the attributes of a user. Leaving out the enterprise manager attribute removes the user's manager.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body UserResource true "New attributes of the user"
+// @Success 200 {object} UserResource "User replaced successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "A user with this userName already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	account, err := h.findUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	var resource UserResource
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeError(c, &Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+		return
+	}
+
+	h.saveUser(c, *account, resource)
+}
+
+// @Summary Update a user
+// @Description Apply PATCH operations to a user. Setting active to false deactivates the user and logs them out everywhere.
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param operations body PatchRequest true "Operations to apply"
+// @Success 200 {object} UserResource "User updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid operation"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "A user with this userName already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	account, err := h.findUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	var reqPayload PatchRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		writeError(c, &Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+		return
+	}
+
+	resource := ConvertUserToUserResource(*account)
+	if err := applyPatch(&resource, reqPayload.Operations); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	h.saveUser(c, *account, resource)
+}
+
+// @Summary Deactivate a user
+// @Description Deactivate a user and log them out everywhere. Users are never deleted, so that their one-to-ones stay attributed.
+// @Tags scim
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 "User deactivated successfully"
+// @Failure 401 {object} ErrorResponse "Invalid bearer token"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	account, err := h.findUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if err := h.setActive(c.Request.Context(), *account, false); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// saveUser stores the attributes of resource on account and answers with the updated user.
+func (h *SCIMHandler) saveUser(c *gin.Context, account user.User, resource UserResource) {
+	ctx := c.Request.Context()
+
+	if err := validateResource(&resource); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if resource.UserName != account.Email {
+		if err := h.checkUserNameAvailable(ctx, resource.UserName); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	var manager *user.User
+	if id := managerID(resource); id != "" {
+		var err error
+		if manager, err = h.findManager(ctx, id, account.ID); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	if resource.UserName != account.Email || resource.Name.GivenName != account.FirstName ||
+		resource.Name.FamilyName != account.LastName || resource.ExternalID != account.ExternalID {
+		err := h.UserRepo.UpdateIdentity(ctx, account.ID, resource.UserName, resource.Name.GivenName, resource.Name.FamilyName, resource.ExternalID)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	if resource.Active != nil && *resource.Active != account.Active() {
+		if err := h.setActive(ctx, account, *resource.Active); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	var managerID *primitive.ObjectID
+	if manager != nil {
+		managerID = &manager.ID
+	}
+	if err := h.setManager(ctx, account, managerID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	updated, err := h.UserRepo.GetUserByID(ctx, account.ID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeResource(c, http.StatusOK, ConvertUserToUserResource(*updated))
+}
+
+// setActive deactivates or reactivates a user. Deactivated users are logged out on every device.
+func (h *SCIMHandler) setActive(c context.Context, account user.User, active bool) error {
+	if err := h.UserRepo.SetActive(c, account.ID, active); err != nil {
+		return err
+	}
+	if active {
+		return nil
+	}
+
+	return h.AuthRepo.RevokeAllUserTokens(c, account.ID)
+}
+
+// setManager changes the user's manager when it differs. A manager that would close a cycle is
+// an invalid value.
+func (h *SCIMHandler) setManager(c context.Context, account user.User, managerID *primitive.ObjectID) error {
+	if account.ReportsTo == nil && managerID == nil {
+		return nil
+	}
+	if account.ReportsTo != nil && managerID != nil && *account.ReportsTo == *managerID {
+		return nil
+	}
+
+	if err := h.UserRepo.SetManager(c, account.ID, managerID); err != nil {
+		if user.IsReportingLineError(err) {
+			return invalidValue(err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (h *SCIMHandler) findUser(c context.Context, id string) (*user.User, error) {
+	notFound := &Error{Status: http.StatusNotFound, Detail: "User " + id + " not found"}
+
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, notFound
+	}
+
+	account, err := h.UserRepo.GetUserByID(c, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, notFound
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// findManager looks up the manager a user is assigned. userID is the user being updated, a user
+// cannot be their own manager.
+func (h *SCIMHandler) findManager(c context.Context, id string, userID primitive.ObjectID) (*user.User, error) {
+	managerID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidValue("Unknown manager " + id)
+	}
+	if managerID == userID {
+		return nil, invalidValue("A user cannot be their own manager")
+	}
+
+	manager, err := h.UserRepo.GetUserByID(c, managerID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, invalidValue("Unknown manager " + id)
+		}
+		return nil, err
+	}
+
+	return manager, nil
+}
+
+func (h *SCIMHandler) checkUserNameAvailable(c context.Context, userName string) error {
+	_, err := h.UserRepo.GetUserByEmail(c, userName)
+	if err == nil {
+		return &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "A user with this userName already exists"}
+	} else if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	return nil
+}
+
+// validateResource checks the attributes of a user sent by the client and fills in the name so
+// that it can be read without nil checks.
+func validateResource(resource *UserResource) error {
+	address, err := mail.ParseAddress(resource.UserName)
+	if err != nil || address.Address != resource.UserName {
+		return invalidValue("userName must be an email address")
+	}
+
+	if resource.Name == nil {
+		resource.Name = &Name{}
+	}
+
+	return nil
+}
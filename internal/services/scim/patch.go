@@ -0,0 +1,173 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var enterpriseManagerPath = strings.ToLower(SchemaEnterpriseUser + ":manager")
+
+// applyPatch applies the operations of a PATCH request to resource in order. Attributes this
+// service does not store, such as phone numbers or titles, are ignored rather than rejected, as
+// provisioning clients send whatever their attribute mapping holds.
+func applyPatch(resource *UserResource, operations []PatchOperation) error {
+	for _, operation := range operations {
+		switch strings.ToLower(operation.Op) {
+		case "add", "replace":
+			if operation.Path == "" {
+				if err := setAttributes(resource, operation.Value); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := setAttribute(resource, operation.Path, operation.Value); err != nil {
+				return err
+			}
+		case "remove":
+			if operation.Path == "" {
+				return &Error{Status: http.StatusBadRequest, ScimType: "noTarget", Detail: "remove operations require a path"}
+			}
+			if err := removeAttribute(resource, operation.Path); err != nil {
+				return err
+			}
+		default:
+			return &Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "Unknown operation " + operation.Op}
+		}
+	}
+
+	return nil
+}
+
+// setAttributes applies a value object, whose keys are attribute paths.
+func setAttributes(resource *UserResource, value json.RawMessage) error {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(value, &attributes); err != nil {
+		return invalidValue("Expected an object of attributes")
+	}
+
+	for path, attributeValue := range attributes {
+		if strings.EqualFold(path, SchemaEnterpriseUser) {
+			var extension map[string]json.RawMessage
+			if err := json.Unmarshal(attributeValue, &extension); err != nil {
+				return invalidValue("Expected an object for " + path)
+			}
+			for name, extensionValue := range extension {
+				if err := setAttribute(resource, SchemaEnterpriseUser+":"+name, extensionValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := setAttribute(resource, path, attributeValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setAttribute(resource *UserResource, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return invalidValue("active must be true or false")
+		}
+		resource.Active = &active
+	case "username":
+		return decodeString(value, &resource.UserName, path)
+	case "externalid":
+		return decodeString(value, &resource.ExternalID, path)
+	case "name":
+		var name Name
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalidValue("Expected an object for name")
+		}
+		if name.GivenName != "" {
+			resourceName(resource).GivenName = name.GivenName
+		}
+		if name.FamilyName != "" {
+			resourceName(resource).FamilyName = name.FamilyName
+		}
+	case "name.givenname":
+		return decodeString(value, &resourceName(resource).GivenName, path)
+	case "name.familyname":
+		return decodeString(value, &resourceName(resource).FamilyName, path)
+	case enterpriseManagerPath:
+		id, err := parseManager(value)
+		if err != nil {
+			return err
+		}
+		resource.Enterprise = &EnterpriseUser{Manager: &Manager{Value: id}}
+	}
+
+	return nil
+}
+
+func removeAttribute(resource *UserResource, path string) error {
+	switch strings.ToLower(path) {
+	case "externalid":
+		resource.ExternalID = ""
+	case "name.givenname":
+		resourceName(resource).GivenName = ""
+	case "name.familyname":
+		resourceName(resource).FamilyName = ""
+	case enterpriseManagerPath:
+		resource.Enterprise = nil
+	case "active", "username":
+		return &Error{Status: http.StatusBadRequest, ScimType: "mutability", Detail: path + " cannot be removed"}
+	}
+
+	return nil
+}
+
+func resourceName(resource *UserResource) *Name {
+	if resource.Name == nil {
+		resource.Name = &Name{}
+	}
+	return resource.Name
+}
+
+func decodeString(value json.RawMessage, target *string, path string) error {
+	if err := json.Unmarshal(value, target); err != nil {
+		return invalidValue(path + " must be a string")
+	}
+	return nil
+}
+
+// parseBool reads a boolean. Some clients send booleans as strings, such as "False".
+func parseBool(value json.RawMessage) (bool, error) {
+	var result bool
+	if err := json.Unmarshal(value, &result); err == nil {
+		return result, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		switch strings.ToLower(text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+
+	return false, errors.New("not a boolean")
+}
+
+// parseManager reads the manager's id, sent either on its own or as a manager object.
+func parseManager(value json.RawMessage) (string, error) {
+	var id string
+	if err := json.Unmarshal(value, &id); err == nil {
+		return id, nil
+	}
+
+	var manager Manager
+	if err := json.Unmarshal(value, &manager); err != nil {
+		return "", invalidValue("manager must be an id or an object with a value")
+	}
+	return manager.Value, nil
+}
@@ -0,0 +1,247 @@
+package setup
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/services/user"
+	"one-to-one/internal/tenant"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// SetupHandler creates organisations along with their first admin: the first one through the
+// first-run setup of a fresh deployment, the others on behalf of whoever holds the SETUP_TOKEN.
+type SetupHandler struct {
+	OrgRepo  organisation.OrganisationRepository
+	UserRepo user.UserRepository
+	AuthRepo auth.AuthRepository
+}
+
+func NewSetupHandler(orgRepo organisation.OrganisationRepository, userRepo user.UserRepository, authRepo auth.AuthRepository) *SetupHandler {
+	return &SetupHandler{OrgRepo: orgRepo, UserRepo: userRepo, AuthRepo: authRepo}
+}
+
+// @Summary Get the setup status
+// @Description Report whether the first-run setup still has to be run
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Success 200 {object} SetupStatusResponse "Setup status retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup [get]
+func (h *SetupHandler) GetStatus(c *gin.Context) {
+	_, err := h.OrgRepo.GetBootstrapOrganisation(c.Request.Context())
+	if err != nil && err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved setup status successfully", SetupStatusResponse{SetupRequired: err == mongo.ErrNoDocuments})
+}
+
+// @Summary Run the first-run setup
+// @Description Create the first organisation and its first admin, who is logged in. Users and reports stored before organisations existed join it. The setup can only be run once, and outside local it requires the SETUP_TOKEN.
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param setup body SetupRequest true "Organisation and first admin"
+// @Success 201 {object} user.LoginResponse "Setup completed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters, or password rejected by the password policy"
+// @Failure 401 {object} map[string]interface{} "Invalid setup token"
+// @Failure 403 {object} map[string]interface{} "The setup is disabled"
+// @Failure 409 {object} map[string]interface{} "The organisation has already been set up, the slug is taken, or a user with this email already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup [post]
+func (h *SetupHandler) Setup(c *gin.Context) {
+	var reqPayload SetupRequest
+	if !h.bindRequest(c, &reqPayload) {
+		return
+	}
+
+	if _, err := h.OrgRepo.GetBootstrapOrganisation(c.Request.Context()); err == nil {
+		api.Error(c, http.StatusConflict, "The organisation has already been set up", nil)
+		return
+	} else if err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	h.createOrganisation(c, reqPayload, true)
+}
+
+// @Summary Create an organisation
+// @Description Create another organisation and its first admin, who is logged in. Requires the SETUP_TOKEN, also in local.
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param setup body SetupRequest true "Organisation and first admin"
+// @Success 201 {object} user.LoginResponse "Organisation created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters, or password rejected by the password policy"
+// @Failure 401 {object} map[string]interface{} "Invalid setup token"
+// @Failure 403 {object} map[string]interface{} "Creating organisations is disabled"
+// @Failure 409 {object} map[string]interface{} "The slug is taken"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /setup/organisation [post]
+func (h *SetupHandler) CreateOrganisation(c *gin.Context) {
+	if config.AppConfig().App.SetupToken == "" {
+		api.Error(c, http.StatusForbidden, "Creating organisations is disabled until SETUP_TOKEN is set", nil)
+		return
+	}
+
+	var reqPayload SetupRequest
+	if !h.bindRequest(c, &reqPayload) {
+		return
+	}
+
+	h.createOrganisation(c, reqPayload, false)
+}
+
+// bindRequest reads the request and checks the setup token and the password. It answers the
+// request and returns false when it is rejected.
+func (h *SetupHandler) bindRequest(c *gin.Context, reqPayload *SetupRequest) bool {
+	if err := c.ShouldBindJSON(reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return false
+	}
+	if !slugPattern.MatchString(reqPayload.OrganisationSlug) {
+		api.Error(c, http.StatusBadRequest, "The slug may only contain lowercase letters, digits and single dashes", nil)
+		return false
+	}
+
+	setupToken := config.AppConfig().App.SetupToken
+	if setupToken == "" && config.AppConfig().App.Environment != "local" {
+		api.Error(c, http.StatusForbidden, "The setup is disabled until SETUP_TOKEN is set", nil)
+		return false
+	}
+	if setupToken != "" {
+		expected, presented := sha256.Sum256([]byte(setupToken)), sha256.Sum256([]byte(reqPayload.SetupToken))
+		if subtle.ConstantTimeCompare(expected[:], presented[:]) != 1 {
+			api.Error(c, http.StatusUnauthorized, "Invalid setup token", nil)
+			return false
+		}
+	}
+
+	if err := user.CheckPasswordPolicy(nil, reqPayload.Password); err != nil {
+		if user.IsPasswordPolicyError(err) {
+			api.Error(c, http.StatusBadRequest, err.Error(), nil)
+			return false
+		}
+		log.Println("Failed to check password policy: ", err)
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return false
+	}
+
+	return true
+}
+
+// createOrganisation creates the organisation and its admin, and logs the admin in. The
+// bootstrap organisation also takes in the data stored before organisations existed.
+func (h *SetupHandler) createOrganisation(c *gin.Context, reqPayload SetupRequest, bootstrap bool) {
+	admin, err := newAdmin(reqPayload)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	org := organisation.Organisation{
+		ID:        primitive.NewObjectID(),
+		Name:      reqPayload.OrganisationName,
+		Slug:      reqPayload.OrganisationSlug,
+		Bootstrap: bootstrap,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Creating the organisation first makes sure only one of two racing setups gets to create an admin.
+	if err := h.OrgRepo.CreateOrganisation(c.Request.Context(), org); err != nil {
+		switch err {
+		case organisation.ErrAlreadySetUp:
+			api.Error(c, http.StatusConflict, "The organisation has already been set up", nil)
+		case organisation.ErrSlugTaken:
+			api.Error(c, http.StatusConflict, "An organisation with this slug already exists", nil)
+		default:
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	ctx := tenant.WithOrganisation(c.Request.Context(), org.ID)
+	rollBack := func() {
+		if err := h.OrgRepo.DeleteOrganisation(ctx, org.ID); err != nil {
+			log.Println("Failed to roll back the organisation: ", err)
+		}
+	}
+
+	if bootstrap {
+		if err := h.OrgRepo.AdoptUnscopedData(ctx, org.ID); err != nil {
+			rollBack()
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+
+	if _, err := h.UserRepo.GetUserByEmail(ctx, admin.Email); err == nil {
+		rollBack()
+		api.Error(c, http.StatusConflict, "A user with this email already exists", nil)
+		return
+	} else if err != mongo.ErrNoDocuments {
+		rollBack()
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	createdAdmin, err := h.UserRepo.CreateUser(ctx, admin)
+	if err != nil {
+		rollBack()
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	tokens, err := user.IssueTokens(ctx, h.AuthRepo, createdAdmin, user.NewTokenOptions(c, false))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	loginRes := user.ConvertToLoginResponse(tokens, createdAdmin)
+	loginRes.MFAEnrollmentRequired = org.MFARequired()
+
+	message := "Completed setup successfully"
+	if !bootstrap {
+		message = "Created organisation successfully"
+	}
+	api.Success(c, http.StatusCreated, message, loginRes)
+}
+
+// newAdmin builds the first admin. The email address is trusted, as whoever runs the setup
+// controls the deployment.
+func newAdmin(req SetupRequest) (user.User, error) {
+	hashed, err := user.HashPassword(req.Password)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	admin := user.NewProvisionedUser(req.Email, req.FirstName, req.LastName)
+	admin.Password = hashed
+	admin.Roles = []string{auth.RoleAdmin}
+	admin.EmailVerified = true
+	admin.EmailVerifiedAt = &now
+	admin.CreatedAt = now
+	admin.UpdatedAt = now
+
+	return admin, nil
+}
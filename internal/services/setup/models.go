@@ -0,0 +1,23 @@
+package setup
+
+// ---------------------------------------------------------------------------------------------------
+// ------------------------------------------ CREATE OBJECTS -----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+type SetupRequest struct {
+	OrganisationName string `json:"organisationName" binding:"required,max=100"`
+	OrganisationSlug string `json:"organisationSlug" binding:"required,max=50"` // lowercase letters, digits and dashes, sent in the Tenant header
+	Email            string `json:"email" binding:"required,email"`
+	Password         string `json:"password" binding:"required"`
+	FirstName        string `json:"firstName" binding:"required,alpha"`
+	LastName         string `json:"lastName" binding:"required,alpha"`
+	SetupToken       string `json:"setupToken"` // the value of SETUP_TOKEN, when set
+}
+
+// ---------------------------------------------------------------------------------------------------
+// ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
+// ---------------------------------------------------------------------------------------------------
+
+type SetupStatusResponse struct {
+	SetupRequired bool `json:"setupRequired"`
+}
@@ -0,0 +1,85 @@
+package user
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// AvatarSizes are the widths, and heights, in pixels of the square images stored for an avatar.
+var AvatarSizes = map[string]int{
+	"small":  32,
+	"medium": 128,
+	"large":  256,
+}
+
+// maxAvatarDimension bounds the width and height of an uploaded image, so that a small file
+// cannot decode into a huge one.
+const maxAvatarDimension = 6000
+
+var (
+	ErrUnsupportedImage = errors.New("avatars must be JPEG, PNG, GIF or WebP images")
+	ErrImageDimensions  = errors.New("the image is too large, it can be at most 6000 pixels wide and high")
+)
+
+var avatarContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ResizeAvatar crops the uploaded image to a centred square and scales it to every size of
+// AvatarSizes, encoded as PNG. The type of the image is read from its content, not from the
+// name or type given by the client.
+func ResizeAvatar(data []byte) (map[string][]byte, error) {
+	if !avatarContentTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width > maxAvatarDimension || config.Height > maxAvatarDimension {
+		return nil, ErrImageDimensions
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	if side == 0 {
+		return nil, ErrUnsupportedImage
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.Rect(x, y, x+side, y+side)
+
+	images := map[string][]byte{}
+	for name, size := range AvatarSizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, square, draw.Src, nil)
+
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, dst); err != nil {
+			return nil, err
+		}
+		images[name] = encoded.Bytes()
+	}
+
+	return images, nil
+}
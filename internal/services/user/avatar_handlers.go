@@ -0,0 +1,166 @@
+package user
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/storage"
+	"one-to-one/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// multipartOverhead is the room left in the request body for the multipart headers and the
+// other form fields around the avatar.
+const multipartOverhead = 64 << 10
+
+// @Summary Upload avatar
+// @Description Replace the profile picture of the current user. The image is cropped to a square and stored in every size of AvatarSizes.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param avatar formData file true "JPEG, PNG, GIF or WebP image"
+// @Success 200 {object} UserResponse "Avatar uploaded successfully"
+// @Failure 400 {object} map[string]interface{} "Missing avatar or image too large in pixels"
+// @Failure 413 {object} map[string]interface{} "Avatar file too large"
+// @Failure 415 {object} map[string]interface{} "Unsupported image type"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/current/avatar [post]
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	maxSize := config.AppConfig().Storage.AvatarMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			api.Error(c, http.StatusRequestEntityTooLarge, "Avatar file too large", nil)
+			return
+		}
+		api.Error(c, http.StatusBadRequest, "Missing avatar file", nil)
+		return
+	}
+	if header.Size > maxSize {
+		api.Error(c, http.StatusRequestEntityTooLarge, "Avatar file too large", nil)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	images, err := ResizeAvatar(data)
+	if err != nil {
+		switch err {
+		case ErrUnsupportedImage:
+			api.Error(c, http.StatusUnsupportedMediaType, err.Error(), nil)
+		case ErrImageDimensions:
+			api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		}
+		return
+	}
+
+	// Every upload gets new keys, so that caches never serve the previous picture.
+	avatar := &Avatar{
+		URLs:      map[string]string{},
+		Keys:      map[string]string{},
+		UpdatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	prefix := "avatars/" + userID.Hex() + "/" + utils.GenerateID()
+	for size, image := range images {
+		key := prefix + "-" + size + ".png"
+		if err := h.Storage.Put(c.Request.Context(), key, "image/png", image); err != nil {
+			h.deleteAvatarImages(c, avatar)
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		avatar.Keys[size] = key
+		avatar.URLs[size] = h.Storage.URL(key)
+	}
+
+	user, previous, err := h.Repo.SetAvatar(c.Request.Context(), userID, avatar)
+	if err != nil {
+		h.deleteAvatarImages(c, avatar)
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	h.deleteAvatarImages(c, previous)
+
+	api.Success(c, http.StatusOK, "Uploaded avatar successfully", ConvertUserToUserResponse(*user))
+}
+
+// @Summary Delete avatar
+// @Description Remove the profile picture of the current user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} UserResponse "Avatar deleted successfully"
+// @Failure 404 {object} map[string]interface{} "No avatar to delete"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/current/avatar [delete]
+func (h *UserHandler) DeleteAvatar(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	user, previous, err := h.Repo.SetAvatar(c.Request.Context(), userID, nil)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if previous == nil {
+		api.Error(c, http.StatusNotFound, "No avatar to delete", nil)
+		return
+	}
+	h.deleteAvatarImages(c, previous)
+
+	api.Success(c, http.StatusOK, "Deleted avatar successfully", ConvertUserToUserResponse(*user))
+}
+
+// deleteAvatarImages removes the stored images of an avatar that is no longer used. A failure
+// only leaves an unreferenced file behind, so it is logged rather than returned.
+func (h *UserHandler) deleteAvatarImages(c *gin.Context, avatar *Avatar) {
+	if avatar == nil {
+		return
+	}
+	for _, key := range avatar.Keys {
+		if err := h.Storage.Delete(c.Request.Context(), key); err != nil && err != storage.ErrNotFound {
+			log.Println("Failed to delete avatar image "+key+": ", err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResizeAvatarCropsToCentredSquares(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	// A landscape image whose centred square is blue and whose sides, cropped away, are red.
+	src := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 200; y++ {
+			if x < 50 || x >= 250 {
+				src.Set(x, y, red)
+			} else {
+				src.Set(x, y, blue)
+			}
+		}
+	}
+
+	images, err := ResizeAvatar(encodePNG(t, src))
+	if err != nil {
+		t.Fatalf("ResizeAvatar: %v", err)
+	}
+	if len(images) != len(AvatarSizes) {
+		t.Fatalf("got %d sizes, want %d", len(images), len(AvatarSizes))
+	}
+
+	for name, size := range AvatarSizes {
+		img, err := png.Decode(bytes.NewReader(images[name]))
+		if err != nil {
+			t.Fatalf("%s is not a PNG: %v", name, err)
+		}
+		if bounds := img.Bounds(); bounds.Dx() != size || bounds.Dy() != size {
+			t.Errorf("%s is %dx%d, want %dx%d", name, bounds.Dx(), bounds.Dy(), size, size)
+		}
+		for _, p := range []image.Point{{0, 0}, {size - 1, 0}, {size / 2, size / 2}, {0, size - 1}, {size - 1, size - 1}} {
+			r, _, b, _ := img.At(p.X, p.Y).RGBA()
+			if r > 0x1000 || b < 0xf000 {
+				t.Errorf("%s pixel %v is not blue, the crop is off centre", name, p)
+			}
+		}
+	}
+}
+
+func TestResizeAvatarRejectsOtherContent(t *testing.T) {
+	inputs := map[string][]byte{
+		"text":      []byte("definitely not an image"),
+		"svg":       []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`),
+		"truncated": encodePNG(t, image.NewRGBA(image.Rect(0, 0, 10, 10)))[:40],
+	}
+
+	for name, data := range inputs {
+		if _, err := ResizeAvatar(data); err != ErrUnsupportedImage {
+			t.Errorf("%s: err = %v, want ErrUnsupportedImage", name, err)
+		}
+	}
+}
+
+func TestResizeAvatarRejectsHugeDimensions(t *testing.T) {
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, maxAvatarDimension+1, 1)))
+
+	if _, err := ResizeAvatar(data); err != ErrImageDimensions {
+		t.Errorf("err = %v, want ErrImageDimensions", err)
+	}
+}
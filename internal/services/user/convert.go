@@ -1,45 +1,233 @@
 package user
 
 import (
-	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"golang.org/x/crypto/bcrypt"
+	"one-to-one/internal/services/auth"
+	"one-to-one/pkg/utils"
 )
 
 func ConvertCreateUserRequestToUser(req CreateUserRequest) (User, error) {
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashed, err := HashPassword(req.Password)
 	if err != nil {
 		return User{}, err
 	}
 
-	defaultReportsTo, err := primitive.ObjectIDFromHex("6695a2379a9e246dc998afc7")
-	if err != nil {
-		return User{}, fmt.Errorf("invalid default ReportsTo ObjectID: %v", err)
-	}
+	user := NewProvisionedUser(req.Email, req.FirstName, req.LastName)
+	user.Password = hashed
+
+	return user, nil
+}
 
+// NewProvisionedUser builds a user without a password, as created for accounts provisioned
+// by an external identity provider. It applies the same defaults as self sign-up. The user
+// reports to nobody until a manager is assigned, see AssignDefaultManager.
+func NewProvisionedUser(email string, firstName string, lastName string) User {
 	return User{
 		ID:        primitive.NewObjectID(),
-		Password:  string(hashed),
-		Email:     req.Email,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+		Email:     email,
+		FirstName: firstName,
+		LastName:  lastName,
+		Roles:     []string{auth.RoleEmployee},
 		Reportees: []primitive.ObjectID{},
-		ReportsTo: &defaultReportsTo,
-	}, nil
+	}
 }
 
 func ConvertUserToUserResponse(user User) UserResponse {
 	reportees := make([]string, len(user.Reportees))
+	for i, reportee := range user.Reportees {
+		reportees[i] = reportee.Hex()
+	}
+
+	var reportsTo *string
+	if user.ReportsTo != nil {
+		reportsTo = utils.StringPtr(user.ReportsTo.Hex())
+	}
+
+	var avatar map[string]string
+	if user.Avatar != nil {
+		avatar = user.Avatar.URLs
+	}
 
 	return UserResponse{
 		ID: user.ID.Hex(),
 
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		ReportsTo: nil,
-		Reportees: reportees,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		JobTitle:      user.JobTitle,
+		Department:    user.Department,
+		Location:      user.Location,
+		Timezone:      user.Timezone,
+		Locale:        user.Locale,
+		Pronouns:      user.Pronouns,
+		Avatar:        avatar,
+		Roles:         user.EffectiveRoles(),
+		MFAEnabled:    user.MFAEnabled(),
+		ReportsTo:     reportsTo,
+		Reportees:     reportees,
+		CreatedAt:     formatDateTime(user.CreatedAt),
+		UpdatedAt:     formatDateTime(user.UpdatedAt),
+	}
+}
+
+func ConvertUsersToUserResponses(users []User) []UserResponse {
+	responses := make([]UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = ConvertUserToUserResponse(user)
+	}
+	return responses
+}
+
+// formatDateTime formats a stored time in RFC 3339, or returns an empty string for documents
+// written before the time was kept.
+func formatDateTime(value primitive.DateTime) string {
+	if value == 0 {
+		return ""
+	}
+	return value.Time().UTC().Format(time.RFC3339)
+}
+
+// ConvertUpdateProfileRequestToUpdate lists the fields to set and to clear for a profile update.
+func ConvertUpdateProfileRequestToUpdate(req UpdateProfileRequest) (bson.M, bson.M) {
+	set, unset := bson.M{}, bson.M{}
+	fields := map[string]*string{
+		"firstName":  req.FirstName,
+		"lastName":   req.LastName,
+		"jobTitle":   req.JobTitle,
+		"department": req.Department,
+		"location":   req.Location,
+		"timezone":   req.Timezone,
+		"locale":     req.Locale,
+		"pronouns":   req.Pronouns,
+	}
+	for field, value := range fields {
+		if value == nil {
+			continue
+		}
+		if trimmed := strings.TrimSpace(*value); trimmed != "" {
+			set[field] = trimmed
+		} else {
+			unset[field] = ""
+		}
+	}
+	return set, unset
+}
+
+func ConvertToLoginResponse(tokens TokenResponse, user User) LoginResponse {
+	return LoginResponse{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         ConvertUserToUserResponse(user),
+	}
+}
+
+func ConvertToPersonalAccessTokenResponse(token auth.PersonalAccessToken) PersonalAccessTokenResponse {
+	response := PersonalAccessTokenResponse{
+		ID:        token.ID.Hex(),
+		Name:      token.Name,
+		Prefix:    token.Prefix,
+		Scopes:    token.Scopes,
+		Revoked:   token.RevokedAt != nil,
+		CreatedAt: token.CreatedAt.Time(),
+	}
+
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.Time()
+		response.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.Time()
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
+
+func ConvertToSessionResponse(session auth.Session, currentSessionID string) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID.Hex(),
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt.Time(),
+		LastSeenAt: session.LastSeenAt.Time(),
+		Current:    session.ID.Hex() == currentSessionID,
+	}
+}
+
+// BuildOrgChart arranges the users below root into a tree, each level sorted by name.
+func BuildOrgChart(root UserSummary, entries []OrgChartEntry) OrgChartNode {
+	children := map[primitive.ObjectID][]UserSummary{}
+	for _, entry := range entries {
+		if entry.ReportsTo != nil {
+			children[*entry.ReportsTo] = append(children[*entry.ReportsTo], entry.UserSummary)
+		}
+	}
+
+	// A cycle left in the data would lead back to a user already in the tree.
+	seen := map[primitive.ObjectID]bool{}
+
+	var build func(user UserSummary) OrgChartNode
+	build = func(user UserSummary) OrgChartNode {
+		seen[user.ID] = true
+
+		reportees := []UserSummary{}
+		for _, reportee := range children[user.ID] {
+			if !seen[reportee.ID] {
+				reportees = append(reportees, reportee)
+			}
+		}
+		sort.Slice(reportees, func(i, j int) bool {
+			if reportees[i].LastName != reportees[j].LastName {
+				return reportees[i].LastName < reportees[j].LastName
+			}
+			return reportees[i].FirstName < reportees[j].FirstName
+		})
+
+		node := OrgChartNode{User: user, Reportees: make([]OrgChartNode, len(reportees))}
+		for i, reportee := range reportees {
+			node.Reportees[i] = build(reportee)
+		}
+		return node
+	}
+
+	return build(root)
+}
+
+// ConvertToAncestors orders the managers above a user from the closest to the top of the
+// organisation.
+func ConvertToAncestors(entries []OrgChartEntry) []UserSummary {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Depth < entries[j].Depth })
+
+	ancestors := make([]UserSummary, len(entries))
+	for i, entry := range entries {
+		ancestors[i] = entry.UserSummary
+	}
+	return ancestors
+}
+
+// ConvertToManagerHistory pairs the reporting lines of a user with the managers they name. A
+// manager who no longer exists is shown by ID only.
+func ConvertToManagerHistory(lines []ReportingLine, managers map[primitive.ObjectID]UserSummary) []ManagerHistoryEntry {
+	history := make([]ManagerHistoryEntry, len(lines))
+	for i, line := range lines {
+		manager, ok := managers[line.ManagerID]
+		if !ok {
+			manager = UserSummary{ID: line.ManagerID}
+		}
+
+		history[i] = ManagerHistoryEntry{Manager: manager, ValidFrom: line.ValidFrom.Time()}
+		if line.ValidTo != nil {
+			validTo := line.ValidTo.Time()
+			history[i].ValidTo = &validTo
+		}
 	}
+	return history
 }
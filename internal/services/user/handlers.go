@@ -1,29 +1,46 @@
 package user
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
 	"net/http"
 	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/mailer"
 	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/audit"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/services/organisation"
+	"one-to-one/internal/storage"
+	"strconv"
+	"time"
 )
 
 type UserHandler struct {
-	Repo UserRepository
+	Repo      UserRepository
+	AuthRepo  auth.AuthRepository
+	AuditRepo audit.AuditRepository
+	OrgRepo   organisation.OrganisationRepository
+	Mailer    mailer.Mailer
+	Storage   storage.Storage
 }
 
-func NewUserHandler(repo UserRepository) *UserHandler {
-	return &UserHandler{Repo: repo}
+func NewUserHandler(repo UserRepository, authRepo auth.AuthRepository, auditRepo audit.AuditRepository, orgRepo organisation.OrganisationRepository, mail mailer.Mailer, store storage.Storage) *UserHandler {
+	return &UserHandler{Repo: repo, AuthRepo: authRepo, AuditRepo: auditRepo, OrgRepo: orgRepo, Mailer: mail, Storage: store}
 }
 
 // @Summary Create a new user
-// @Description Create a new user
+// @Description Sign up to the organisation named by the Tenant header, the first organisation when left out. Organisations other than the first only take new members through invitations, unless they allow sign-ups.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body CreateUserRequest true "User object to be created"
 // @Success 201 {object} UserResponse "User created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 403 {object} map[string]interface{} "The organisation does not allow sign-ups"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /user/create [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
@@ -33,6 +50,21 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	allowed, err := h.OrgRepo.SignupAllowed(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+	if !allowed {
+		api.Error(c, http.StatusForbidden, "This organisation does not allow sign-ups, ask an admin for an invitation", nil)
+		return
+	}
+
+	if err := CheckPasswordPolicy(nil, reqPayload.Password); err != nil {
+		h.passwordPolicyFailed(c, err)
+		return
+	}
+
 	mongoUser, err := ConvertCreateUserRequestToUser(reqPayload)
 	if err != nil {
 		api.Error(c, http.StatusBadRequest, err.Error(), nil)
@@ -45,7 +77,15 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusCreated, "Created user successfully", createdUser)
+	if err := AssignDefaultManager(c.Request.Context(), h.Repo, h.OrgRepo, &createdUser); err != nil {
+		log.Println("Failed to assign the default manager: ", err)
+	}
+
+	if err := h.sendVerificationEmail(c.Request.Context(), createdUser); err != nil {
+		log.Println("Failed to send verification email: ", err)
+	}
+
+	api.Success(c, http.StatusCreated, "Created user successfully", ConvertUserToUserResponse(createdUser))
 }
 
 // @Summary Get all users
@@ -54,7 +94,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} []UserResponse "Users retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
 // @Router /user/all [get]
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	users, err := h.Repo.GetAllUsers(c.Request.Context())
@@ -63,7 +105,7 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Retrieved users successfully", users)
+	api.Success(c, http.StatusOK, "Retrieved users successfully", ConvertUsersToUserResponses(users))
 }
 
 // @Summary Get user by email
@@ -76,6 +118,7 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
 // @Router /user/email/{email} [get]
 func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 	email := c.Param("email")
@@ -90,18 +133,21 @@ func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Retrieved user successfully", user)
+	api.Success(c, http.StatusOK, "Retrieved user successfully", ConvertUserToUserResponse(*user))
 }
 
 // @Summary Login user
-// @Description Login user
+// @Description Login user. Repeated failures lock the account and the client IP address for an increasing amount of time.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body LoginRequest true "User login credentials"
 // @Success 200 {object} LoginResponse "User logged in successfully"
+// @Success 202 {object} MFAChallengeResponse "Password accepted, a TOTP code is required to finish logging in"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
-// @Failure 401 {object} map[string]interface{} "Invalid credentials"
+// @Failure 401 {object} map[string]interface{} "Invalid email or password"
+// @Failure 403 {object} map[string]interface{} "Email address has not been verified or account has been deactivated"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /user/login [post]
 func (h *UserHandler) LoginUser(c *gin.Context) {
@@ -111,35 +157,545 @@ func (h *UserHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
+	if h.loginLocked(c, reqPayload.Email) {
+		return
+	}
+
 	user, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.Email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if !CheckPassword(user, reqPayload.Password) {
+		h.loginFailed(c, reqPayload.Email)
+		return
+	}
+
+	if !user.Active() {
+		api.Error(c, http.StatusForbidden, "Account has been deactivated", nil)
+		return
+	}
+
+	if config.AppConfig().Auth.EmailVerification == middleware.EmailVerificationLogin && !user.EmailVerified {
+		api.Error(c, http.StatusForbidden, "Email address has not been verified", nil)
+		return
+	}
+
+	h.upgradePasswordHash(c.Request.Context(), *user, reqPayload.Password)
+
+	// The failures are only cleared once the second factor passed too, otherwise the password
+	// alone would reset the count of guessed codes.
+	if user.MFAEnabled() {
+		SendMFAChallenge(c, *user)
+		return
+	}
+
+	if err := auth.ClearAccountLoginFailures(c.Request.Context(), h.AuthRepo, user.Email); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	tokens, err := IssueTokens(c.Request.Context(), h.AuthRepo, *user, NewTokenOptions(c, false))
 	if err != nil {
-		api.Error(c, http.StatusNotFound, "User not found", nil)
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	mfaRequired, err := h.OrgRepo.MFARequired(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(reqPayload.Password)); err != nil {
-		api.Error(c, http.StatusUnauthorized, "Invalid credentials", nil)
+	loginRes := ConvertToLoginResponse(tokens, *user)
+	loginRes.MFAEnrollmentRequired = mfaRequired
+
+	api.Success(c, http.StatusOK, "User logged in successfully", loginRes)
+}
+
+// upgradePasswordHash rehashes the password of a user whose hash is outdated, now that the
+// plain text password is known. A failure only means the upgrade is retried on the next login.
+func (h *UserHandler) upgradePasswordHash(c context.Context, user User, password string) {
+	if !PasswordNeedsRehash(user.Password) {
 		return
 	}
 
-	token, err := middleware.GenerateJWTToken(user.Email, user.ID.Hex())
+	hashed, err := HashPassword(password)
+	if err == nil {
+		err = h.Repo.RehashPassword(c, user.ID, user.Password, hashed)
+	}
+	if err != nil {
+		log.Println("Failed to upgrade password hash: ", err)
+	}
+}
+
+// loginLocked answers with 429 when the account or the client IP address is locked out.
+func (h *UserHandler) loginLocked(c *gin.Context, email string) bool {
+	lockedUntil, err := auth.LoginLockedUntil(c.Request.Context(), h.AuthRepo, auth.AccountLoginKey(c.Request.Context(), email), auth.IPLoginKey(c.ClientIP()))
 	if err != nil {
 		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return true
+	}
+
+	if lockedUntil.IsZero() {
+		return false
+	}
+
+	retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	api.Error(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+	return true
+}
+
+// loginFailed records a failed login and answers with the same error whether the email is
+// unknown or the password is wrong.
+func (h *UserHandler) loginFailed(c *gin.Context, email string) {
+	if err := auth.RecordLoginFailure(c.Request.Context(), h.AuthRepo, email, c.ClientIP()); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Error(c, http.StatusUnauthorized, "Invalid email or password", nil)
+}
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used only once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} TokenResponse "Token refreshed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 401 {object} map[string]interface{} "Invalid or reused refresh token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/token/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var reqPayload RefreshTokenRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tokens, err := RotateRefreshToken(c.Request.Context(), h.AuthRepo, h.Repo, reqPayload.RefreshToken, c.ClientIP())
+	if err != nil {
+		switch err {
+		case ErrInvalidRefreshToken, ErrRefreshTokenReused:
+			api.Error(c, http.StatusUnauthorized, err.Error(), nil)
+		default:
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		}
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// @Summary Logout user
+// @Description End the current session, revoking its access and refresh tokens. A refresh token can be given to end its session as well, for tokens issued before sessions were recorded.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} map[string]interface{} "User logged out successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var reqPayload LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&reqPayload); err != nil {
+			api.Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	if err := h.AuthRepo.RevokeToken(c.Request.Context(), c.GetString("jti"), c.GetTime("tokenExpiresAt")); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	userRes := UserResponse{
-		ID:        user.ID.Hex(),
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		ReportsTo: nil,
-		Reportees: []string{},
+	if sessionID, err := primitive.ObjectIDFromHex(c.GetString("sessionId")); err == nil {
+		if err := h.AuthRepo.RevokeRefreshTokenFamily(c.Request.Context(), sessionID); err != nil {
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
 	}
 
-	api.Success(c, http.StatusOK, "User logged in successfully", LoginResponse{
-		Token: token,
-		User:  userRes,
+	if reqPayload.RefreshToken != "" {
+		stored, err := h.AuthRepo.GetRefreshTokenByHash(c.Request.Context(), auth.HashToken(reqPayload.RefreshToken))
+		if err != nil && err != mongo.ErrNoDocuments {
+			api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+
+		// Only the owner of a refresh token may revoke it.
+		if stored != nil && stored.UserID.Hex() == c.GetString("userId") {
+			if err := h.AuthRepo.RevokeRefreshTokenFamily(c.Request.Context(), stored.FamilyID); err != nil {
+				api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+				return
+			}
+		}
+	}
+
+	api.Success(c, http.StatusOK, "User logged out successfully", nil)
+}
+
+// @Summary Logout user everywhere
+// @Description Revoke every access and refresh token issued to the current user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "User logged out everywhere successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/logout/all [post]
+func (h *UserHandler) LogoutEverywhere(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.AuthRepo.RevokeAllUserTokens(c.Request.Context(), userID); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "User logged out everywhere successfully", nil)
+}
+
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the email belongs to an account.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} map[string]interface{} "Password reset requested"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/password/forgot [post]
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var reqPayload ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	const message = "If an account exists for this email, a password reset link has been sent"
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.Email)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+		api.Success(c, http.StatusOK, message, nil)
+		return
+	}
+
+	if !user.Active() {
+		api.Success(c, http.StatusOK, message, nil)
+		return
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	now := time.Now()
+	expiresIn := time.Minute * time.Duration(config.AppConfig().Auth.ResetTokenExpire)
+	err = h.AuthRepo.CreatePasswordResetToken(c.Request.Context(), auth.PasswordResetToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(expiresIn)),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	link := ClientLink("/reset-password", token, user.OrganisationID)
+	err = h.Mailer.Send(c.Request.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your OneToOne password",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"Use the link below to choose a new password. The link expires in " + strconv.Itoa(config.AppConfig().Auth.ResetTokenExpire) + " minutes and can only be used once.\n\n" +
+			link + "\n\n" +
+			"If you did not ask for a password reset you can ignore this email.\n",
+	})
+	if err != nil {
+		// Failing loudly here would tell the caller that the account exists.
+		log.Println("Failed to send password reset email: ", err)
+	}
+
+	api.Success(c, http.StatusOK, message, nil)
+}
+
+// @Summary Reset password
+// @Description Set a new password using a token from a password reset email. Every existing session of the user is logged out.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password reset successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, invalid or expired token, or password rejected by the password policy"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/password/reset [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var reqPayload ResetPasswordRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// Check the new password before consuming the token, so a rejected password can be retried.
+	tokenHash := auth.HashToken(reqPayload.Token)
+	resetToken, err := h.AuthRepo.GetPasswordResetToken(c.Request.Context(), tokenHash)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired reset token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), resetToken.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired reset token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := CheckPasswordPolicy(user, reqPayload.Password); err != nil {
+		h.passwordPolicyFailed(c, err)
+		return
+	}
+
+	if _, err := h.AuthRepo.ConsumePasswordResetToken(c.Request.Context(), tokenHash); err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired reset token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	hashed, err := HashPassword(reqPayload.Password)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := h.Repo.UpdatePassword(c.Request.Context(), user.ID, hashed, PasswordHistoryAfterChange(*user)); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := h.AuthRepo.RevokeAllUserTokens(c.Request.Context(), user.ID); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// @Summary Change password
+// @Description Change the password of the current user. Every other session is logged out and new tokens are returned for this one.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} TokenResponse "Password changed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, wrong current password, or password rejected by the password policy"
+// @Failure 429 {object} map[string]interface{} "Too many failed attempts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/password/change [post]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var reqPayload ChangePasswordRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if user.Password == "" {
+		api.Error(c, http.StatusBadRequest, "Account has no password, use the password reset instead", nil)
+		return
+	}
+
+	// Guessing the current password with a stolen session counts towards the login lockout.
+	if h.loginLocked(c, user.Email) {
+		return
+	}
+
+	if !CheckPassword(user, reqPayload.CurrentPassword) {
+		if err := auth.RecordLoginFailure(c.Request.Context(), h.AuthRepo, user.Email, c.ClientIP()); err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+		api.Error(c, http.StatusBadRequest, "Current password is incorrect", nil)
+		return
+	}
+
+	if err := CheckPasswordPolicy(user, reqPayload.NewPassword); err != nil {
+		h.passwordPolicyFailed(c, err)
+		return
+	}
+
+	hashed, err := HashPassword(reqPayload.NewPassword)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := h.Repo.UpdatePassword(c.Request.Context(), user.ID, hashed, PasswordHistoryAfterChange(*user)); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := h.AuthRepo.RevokeAllUserTokens(c.Request.Context(), user.ID); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	tokens, err := IssueTokens(c.Request.Context(), h.AuthRepo, *user, NewTokenOptions(c, c.GetBool("mfa")))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Password changed successfully", tokens)
+}
+
+// passwordPolicyFailed answers with 400 when a password breaks the policy and with 500 when
+// the policy could not be checked.
+func (h *UserHandler) passwordPolicyFailed(c *gin.Context, err error) {
+	if IsPasswordPolicyError(err) {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	log.Println("Failed to check password policy: ", err)
+	api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+}
+
+// @Summary Verify email address
+// @Description Confirm the email address of an account using the token from a verification email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]interface{} "Email verified successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, or invalid or expired token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/email/verify [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var reqPayload VerifyEmailRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	email, userId, err := middleware.ParseSignedToken(middleware.PurposeEmailVerification, reqPayload.Token)
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid or expired verification token", nil)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid or expired verification token", nil)
+		return
+	}
+
+	if err := h.Repo.MarkEmailVerified(c.Request.Context(), userID, email); err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusBadRequest, "Invalid or expired verification token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Email verified successfully", nil)
+}
+
+// @Summary Resend verification email
+// @Description Send a new verification email. The response is the same whether or not the email belongs to an unverified account.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Account email"
+// @Success 200 {object} map[string]interface{} "Verification email requested"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/email/verify/resend [post]
+func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
+	var reqPayload ResendVerificationRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	const message = "If an unverified account exists for this email, a verification link has been sent"
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.Email)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return
+		}
+		api.Success(c, http.StatusOK, message, nil)
+		return
+	}
+
+	if !user.EmailVerified {
+		if err := h.sendVerificationEmail(c.Request.Context(), *user); err != nil {
+			log.Println("Failed to send verification email: ", err)
+		}
+	}
+
+	api.Success(c, http.StatusOK, message, nil)
+}
+
+// sendVerificationEmail emails the user a signed link that confirms their address.
+func (h *UserHandler) sendVerificationEmail(c context.Context, user User) error {
+	expiresIn := time.Hour * time.Duration(config.AppConfig().Auth.VerificationTokenExpire)
+	token, err := middleware.GenerateSignedToken(middleware.PurposeEmailVerification, user.Email, user.ID.Hex(), expiresIn)
+	if err != nil {
+		return err
+	}
+
+	link := ClientLink("/verify-email", token, user.OrganisationID)
+	return h.Mailer.Send(c, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your OneToOne email address",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"Please confirm your email address by opening the link below. The link expires in " + strconv.Itoa(config.AppConfig().Auth.VerificationTokenExpire) + " hours.\n\n" +
+			link + "\n",
 	})
 }
 
@@ -159,123 +715,269 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Retrieved user successfully", user)
+	api.Success(c, http.StatusOK, "Retrieved user successfully", ConvertUserToUserResponse(*user))
 }
 
-// @Summary Add reportee
-// @Description Add reportee
+// @Summary Update current user
+// @Description Update the profile of the current user. Fields left out are kept; optional fields are cleared with an empty string.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param reportee body AddReporteeRequest true "Reportee object to be added"
-// @Success 200 {object} UserResponse "Reportee added successfully"
+// @Param profile body UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} UserResponse "User updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
-// @Failure 404 {object} map[string]interface{} "Reportee not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
-// @Router /user/reportee/add [post]
-func (h *UserHandler) AddReportee(c *gin.Context) {
-	currentUser, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+// @Router /user/current [patch]
+func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
 	if err != nil {
-		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	h.updateProfile(c, userID)
+}
+
+// @Summary Update a user
+// @Description Update the profile of a user. Fields left out are kept; optional fields are cleared with an empty string. Admin and HR only.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param profile body UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} UserResponse "User updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/{id} [patch]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
 		return
 	}
 
-	var reqPayload AddReporteeRequest
+	h.updateProfile(c, userID)
+}
+
+func (h *UserHandler) updateProfile(c *gin.Context, userID primitive.ObjectID) {
+	var reqPayload UpdateProfileRequest
 	if err := c.ShouldBindJSON(&reqPayload); err != nil {
 		api.Error(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	reportee, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.ReporteeEmail)
+	set, unset := ConvertUpdateProfileRequestToUpdate(reqPayload)
+	user, err := h.Repo.UpdateProfile(c.Request.Context(), userID, set, unset)
 	if err != nil {
-		api.Error(c, http.StatusNotFound, "Reportee not found", nil)
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	if err := h.Repo.AddReportee(c.Request.Context(), currentUser.ID, reportee.ID); err != nil {
-		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
-		return
+	api.Success(c, http.StatusOK, "Updated user successfully", ConvertUserToUserResponse(*user))
+}
+
+// @Summary List roles
+// @Description List the roles that can be assigned to users and the permissions they grant
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {array} RoleResponse "Roles retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Security BearerAuth
+// @Router /user/roles [get]
+func (h *UserHandler) GetRoles(c *gin.Context) {
+	roles := []RoleResponse{}
+	for _, role := range auth.Roles() {
+		roles = append(roles, RoleResponse{Role: role, Permissions: auth.PermissionsForRole(role)})
 	}
 
-	api.Success(c, http.StatusOK, "Added reportee successfully", reportee)
+	api.Success(c, http.StatusOK, "Retrieved roles successfully", roles)
 }
 
-// @Summary Remove reportee
-// @Description Remove reportee
+// @Summary Update user roles
+// @Description Replace the roles of a user. Admin only.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param reportee body RemoveReporteeRequest true "Reportee object to be removed"
-// @Success 200 {object} UserResponse "Reportee removed successfully"
+// @Param id path string true "User ID"
+// @Param roles body UpdateRolesRequest true "New roles"
+// @Success 200 {object} UserResponse "Roles updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
-// @Failure 404 {object} map[string]interface{} "Reportee not found"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
-// @Router /user/reportee/remove [post]
-func (h *UserHandler) RemoveReportee(c *gin.Context) {
-	currentUser, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+// @Router /user/{id}/roles [put]
+func (h *UserHandler) UpdateUserRoles(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
 		return
 	}
 
-	var reqPayload RemoveReporteeRequest
+	var reqPayload UpdateRolesRequest
 	if err := c.ShouldBindJSON(&reqPayload); err != nil {
 		api.Error(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	reportee, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.ReporteeEmail)
+	// Stop admins from locking themselves out of role management.
+	if userID.Hex() == c.GetString("userId") && !auth.HasRole(reqPayload.Roles, auth.RoleAdmin) {
+		api.Error(c, http.StatusBadRequest, "You cannot remove your own admin role", nil)
+		return
+	}
+
+	user, err := h.Repo.UpdateRoles(c.Request.Context(), userID, reqPayload.Roles)
 	if err != nil {
-		api.Error(c, http.StatusNotFound, "Reportee not found", nil)
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	if err := h.Repo.RemoveReportee(c.Request.Context(), currentUser.ID, reportee.ID); err != nil {
+	// Access tokens carry the roles, make the user pick up the new ones on the next refresh.
+	if err := h.AuthRepo.RevokeUserAccessTokens(c.Request.Context(), userID); err != nil {
 		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Removed reportee successfully", reportee)
+	api.Success(c, http.StatusOK, "Updated user roles successfully", ConvertUserToUserResponse(*user))
 }
 
-// @Summary Add reports to user
-// @Description Add reports to user
+// @Summary Unlock a user
+// @Description Clear the failed login attempts of a user so they can log in again right away
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param report body AddReportsToRequest true "Report object to be added"
-// @Success 200 {object} UserResponse "Report added successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "User unlocked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
-// @Router /user/reports-to/add [post]
-func (h *UserHandler) AddReportsToUser(c *gin.Context) {
-	currentUser, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+// @Router /user/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if err := auth.ClearAccountLoginFailures(c.Request.Context(), h.AuthRepo, user.Email); err != nil {
 		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	var reqPayload AddReportsToRequest
+	api.Success(c, http.StatusOK, "Unlocked user successfully", nil)
+}
+
+// @Summary Impersonate a user
+// @Description Issue a short-lived token to act as another user, e.g. to reproduce what they see. Responses to requests made with it are flagged, and every request is written to the audit log. Admins cannot be impersonated.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body ImpersonateRequest true "Reason for impersonating the user"
+// @Success 200 {object} ImpersonationResponse "Impersonation token issued"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/{id}/impersonate [post]
+func (h *UserHandler) ImpersonateUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	var reqPayload ImpersonateRequest
 	if err := c.ShouldBindJSON(&reqPayload); err != nil {
 		api.Error(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	report, err := h.Repo.GetUserByEmail(c.Request.Context(), reqPayload.ReportsToEmail)
+	adminID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
 	if err != nil {
-		api.Error(c, http.StatusNotFound, "User not found", nil)
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	if userID == adminID {
+		api.Error(c, http.StatusBadRequest, "You cannot impersonate yourself", nil)
 		return
 	}
 
-	if err := h.Repo.AddReportsTo(c.Request.Context(), currentUser.ID, report.ID); err != nil {
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
 		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
-	api.Success(c, http.StatusOK, "Added report successfully", report)
-}
+	// Impersonating an admin would hand out every permission without the admin's own login.
+	if auth.HasRole(user.EffectiveRoles(), auth.RoleAdmin) {
+		api.Error(c, http.StatusForbidden, "Admins cannot be impersonated", nil)
+		return
+	}
+
+	expiresIn := time.Minute * time.Duration(config.AppConfig().Auth.ImpersonationExpire)
+	impersonator := api.Impersonation{ImpersonatorID: adminID.Hex(), ImpersonatorEmail: c.GetString("email")}
+	token, err := middleware.GenerateImpersonationToken(middleware.TokenSubject{
+		Email:          user.Email,
+		UserID:         user.ID.Hex(),
+		OrganisationID: user.OrganisationID.Hex(),
+		EmailVerified:  user.EmailVerified,
+		Roles:          user.EffectiveRoles(),
+		MFA:            true,
+	}, impersonator, expiresIn)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
 
+	err = h.AuditRepo.Record(c.Request.Context(), audit.Entry{
+		Action:       audit.ActionImpersonationStart,
+		ActorID:      adminID,
+		ActorEmail:   impersonator.ImpersonatorEmail,
+		SubjectID:    &user.ID,
+		SubjectEmail: user.Email,
+		IP:           c.ClientIP(),
+		Details:      map[string]interface{}{"reason": reqPayload.Reason, "expiresIn": int(expiresIn.Seconds())},
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Impersonation token issued successfully", ImpersonationResponse{
+		Token:     token,
+		ExpiresIn: int(expiresIn.Seconds()),
+		User:      ConvertUserToUserResponse(*user),
+	})
+}
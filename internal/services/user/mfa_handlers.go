@@ -0,0 +1,359 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/config"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recoveryCodeCount is the number of recovery codes handed out at a time.
+const recoveryCodeCount = 10
+
+// SendMFAChallenge answers the first login step of a user with two-factor authentication. The
+// login is finished by LoginMFA.
+func SendMFAChallenge(c *gin.Context, user User) {
+	expiresIn := time.Minute * time.Duration(config.AppConfig().Auth.MFAChallengeExpire)
+	token, err := middleware.GenerateSignedToken(middleware.PurposeMFAChallenge, user.Email, user.ID.Hex(), expiresIn)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusAccepted, "Two-factor authentication required", MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(expiresIn.Seconds()),
+	})
+}
+
+// verifyMFACode accepts either a current TOTP code or one of the user's recovery codes.
+// Each TOTP code and each recovery code can be used only once.
+func (h *UserHandler) verifyMFACode(c context.Context, user User, code string) (bool, error) {
+	if !user.MFAEnabled() {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(user.MFA.Secret, code, time.Now()); ok {
+		return h.Repo.RecordMFAStep(c, user.ID, step)
+	}
+
+	return h.Repo.ConsumeRecoveryCode(c, user.ID, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+}
+
+// checkMFACode verifies a code like verifyMFACode, for the login and for the account settings
+// it guards. Codes are short, so guessing them counts towards the same lockout as passwords.
+// It answers the request and returns false unless the code is accepted.
+func (h *UserHandler) checkMFACode(c *gin.Context, user User, code string) bool {
+	if h.loginLocked(c, user.Email) {
+		return false
+	}
+
+	ok, err := h.verifyMFACode(c.Request.Context(), user, code)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return false
+	}
+	if !ok {
+		if err := auth.RecordLoginFailure(c.Request.Context(), h.AuthRepo, user.Email, c.ClientIP()); err != nil {
+			api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+			return false
+		}
+		api.Error(c, http.StatusUnauthorized, "Invalid authentication code", nil)
+		return false
+	}
+
+	if err := auth.ClearAccountLoginFailures(c.Request.Context(), h.AuthRepo, user.Email); err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return false
+	}
+
+	return true
+}
+
+// newRecoveryCodes returns fresh recovery codes and the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// @Summary Finish two-factor login
+// @Description Exchange the MFA token from the first login step and a TOTP or recovery code for the app tokens
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFALoginRequest true "MFA token and code"
+// @Success 200 {object} LoginResponse "User logged in successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 401 {object} map[string]interface{} "Invalid or expired MFA token, or invalid code"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /user/login/mfa [post]
+func (h *UserHandler) LoginMFA(c *gin.Context) {
+	var reqPayload MFALoginRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	_, userId, err := middleware.ParseSignedToken(middleware.PurposeMFAChallenge, reqPayload.MFAToken)
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if !h.checkMFACode(c, *user, reqPayload.Code) {
+		return
+	}
+
+	tokens, err := IssueTokens(c.Request.Context(), h.AuthRepo, *user, NewTokenOptions(c, true))
+	if err == ErrUserDeactivated {
+		api.Error(c, http.StatusForbidden, "Account has been deactivated", nil)
+		return
+	}
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "User logged in successfully", ConvertToLoginResponse(tokens, *user))
+}
+
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret for the current user. The provisioning URI is meant to be shown as a QR code.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} MFAEnrollmentResponse "TOTP secret generated"
+// @Failure 409 {object} map[string]interface{} "Two-factor authentication is already enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/mfa/enroll [post]
+func (h *UserHandler) EnrollMFA(c *gin.Context) {
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if user.MFAEnabled() {
+		api.Error(c, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	if err := h.Repo.UpdateMFA(c.Request.Context(), user.ID, &MFASettings{PendingSecret: secret}); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Generated two-factor secret successfully", MFAEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(config.AppConfig().Auth.MFAIssuer, user.Email, secret),
+	})
+}
+
+// @Summary Activate two-factor authentication
+// @Description Confirm enrollment with a first TOTP code. The recovery codes are only shown once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "TOTP code"
+// @Success 200 {object} MFARecoveryCodesResponse "Two-factor authentication enabled"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, no enrollment in progress or invalid code"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/mfa/activate [post]
+func (h *UserHandler) ActivateMFA(c *gin.Context) {
+	var reqPayload MFACodeRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if user.MFA == nil || user.MFA.PendingSecret == "" {
+		api.Error(c, http.StatusBadRequest, "No two-factor enrollment in progress", nil)
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.MFA.PendingSecret, reqPayload.Code, time.Now())
+	if !ok {
+		api.Error(c, http.StatusBadRequest, "Invalid authentication code", nil)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	err = h.Repo.UpdateMFA(c.Request.Context(), user.ID, &MFASettings{
+		Enabled:       true,
+		Secret:        user.MFA.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     &now,
+	})
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Enabled two-factor authentication successfully", MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication for the current user. Not allowed while it is enforced.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{} "Two-factor authentication disabled"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, not enabled or enforced"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/mfa/disable [post]
+func (h *UserHandler) DisableMFA(c *gin.Context) {
+	var reqPayload MFACodeRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	mfaRequired, err := h.OrgRepo.MFARequired(c.Request.Context())
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if mfaRequired {
+		api.Error(c, http.StatusBadRequest, "Two-factor authentication is enforced and cannot be disabled", nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if !user.MFAEnabled() {
+		api.Error(c, http.StatusBadRequest, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	if !h.checkMFACode(c, *user, reqPayload.Code) {
+		return
+	}
+
+	if err := h.Repo.UpdateMFA(c.Request.Context(), user.ID, nil); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Disabled two-factor authentication successfully", nil)
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace every recovery code of the current user. The new codes are only shown once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} MFARecoveryCodesResponse "Recovery codes regenerated"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or not enabled"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 429 {object} map[string]interface{} "Too many failed login attempts"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/mfa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var reqPayload MFACodeRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	user, err := h.Repo.GetUserByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if !user.MFAEnabled() {
+		api.Error(c, http.StatusBadRequest, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	if !h.checkMFACode(c, *user, reqPayload.Code) {
+		return
+	}
+
+	// Re-read the settings, verifying the code may have consumed a recovery code or a step.
+	user, err = h.Repo.GetUserByID(c.Request.Context(), user.ID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+
+	settings := *user.MFA
+	settings.RecoveryCodes = hashes
+	if err := h.Repo.UpdateMFA(c.Request.Context(), user.ID, &settings); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Regenerated recovery codes successfully", MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
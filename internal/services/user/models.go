@@ -1,8 +1,11 @@
 package user
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"one-to-one/internal/services/auth"
 )
 
 type Account struct {
@@ -30,11 +33,24 @@ type LoginRequest struct {
 // ---------------------------------------------------------------------------------------------------
 type CreateUserRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required"`
 	FirstName string `json:"firstName" binding:"required,alpha"`
 	LastName  string `json:"lastName" binding:"required,alpha"`
 }
 
+// UpdateProfileRequest changes the fields that are set and leaves the others as they are. The
+// optional fields are cleared by setting them to an empty string.
+type UpdateProfileRequest struct {
+	FirstName  *string `json:"firstName" binding:"omitnil,min=1,max=50,alpha"`
+	LastName   *string `json:"lastName" binding:"omitnil,min=1,max=50,alpha"`
+	JobTitle   *string `json:"jobTitle" binding:"omitnil,max=100"`
+	Department *string `json:"department" binding:"omitnil,max=100"`
+	Location   *string `json:"location" binding:"omitnil,max=100"`
+	Timezone   *string `json:"timezone" binding:"omitnil,eq=|timezone"`         // IANA time zone, e.g. Europe/London
+	Locale     *string `json:"locale" binding:"omitnil,eq=|bcp47_language_tag"` // BCP 47 language tag, e.g. en-GB
+	Pronouns   *string `json:"pronouns" binding:"omitnil,max=30"`
+}
+
 type AddReporteeRequest struct {
 	ReporteeEmail string `json:"reporteeEmail" binding:"required,email"`
 }
@@ -47,36 +63,301 @@ type RemoveReporteeRequest struct {
 	ReporteeEmail string `json:"reporteeEmail" binding:"required,email"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type UpdateRolesRequest struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,oneof=admin hr manager employee"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=reports:read reports:write"`
+	ExpiresInDays *int     `json:"expiresInDays" binding:"omitempty,min=1,max=365"` // never expires when omitted
+}
+
 // ---------------------------------------------------------------------------------------------------
 // ----------------------------------------- RESPONSE OBJECTS ----------------------------------------
 // ---------------------------------------------------------------------------------------------------
 type UserResponse struct {
-	ID        string   `json:"id,omitempty"`
-	Email     string   `json:"email"`
-	FirstName string   `json:"firstName,omitempty"`
-	LastName  string   `json:"lastName,omitempty"`
-	ReportsTo *string  `json:"reportsTo,omitempty"`
-	Reportees []string `json:"reportees,omitempty"`
-	CreatedAt string   `json:"createdAt,omitempty"`
-	UpdatedAt string   `json:"updatedAt,omitempty"`
+	ID            string            `json:"id,omitempty"`
+	Email         string            `json:"email"`
+	EmailVerified bool              `json:"emailVerified"`
+	FirstName     string            `json:"firstName,omitempty"`
+	LastName      string            `json:"lastName,omitempty"`
+	JobTitle      string            `json:"jobTitle,omitempty"`
+	Department    string            `json:"department,omitempty"`
+	Location      string            `json:"location,omitempty"`
+	Timezone      string            `json:"timezone,omitempty"`
+	Locale        string            `json:"locale,omitempty"`
+	Pronouns      string            `json:"pronouns,omitempty"`
+	Avatar        map[string]string `json:"avatar,omitempty"` // URL of the avatar by size, see AvatarSizes
+	Roles         []string          `json:"roles"`
+	MFAEnabled    bool              `json:"mfaEnabled"`
+	ReportsTo     *string           `json:"reportsTo,omitempty"`
+	Reportees     []string          `json:"reportees,omitempty"`
+	CreatedAt     string            `json:"createdAt,omitempty"`
+	UpdatedAt     string            `json:"updatedAt,omitempty"`
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token                 string       `json:"token"`
+	RefreshToken          string       `json:"refreshToken"`
+	ExpiresIn             int          `json:"expiresIn"`
+	User                  UserResponse `json:"user"`
+	MFAEnrollmentRequired bool         `json:"mfaEnrollmentRequired,omitempty"` // two-factor authentication is enforced but not set up yet
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type RoleResponse struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	Revoked    bool       `json:"revoked"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type CreatedPersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"` // only returned once, when the token is created
+}
+
+type ImpersonationResponse struct {
+	Token     string       `json:"token"`
+	ExpiresIn int          `json:"expiresIn"`
+	User      UserResponse `json:"user"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"` // the session of the token used for the request
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// OrgChartNode is a user in the org chart, along with the users reporting to them down to the
+// requested depth.
+type OrgChartNode struct {
+	User      UserSummary    `json:"user"`
+	Reportees []OrgChartNode `json:"reportees"`
+}
+
+// OrgChartResponse holds the reporting tree below a user, or in ancestors mode the chain of
+// managers above the user, from their manager up to the top of the organisation.
+type OrgChartResponse struct {
+	Tree      *OrgChartNode `json:"tree,omitempty"`
+	Ancestors []UserSummary `json:"ancestors,omitempty"`
+}
+
+// ManagerHistoryEntry is a manager the user reported to, from ValidFrom until ValidTo. The
+// current manager has no ValidTo.
+type ManagerHistoryEntry struct {
+	Manager   UserSummary `json:"manager"`
+	ValidFrom time.Time   `json:"validFrom"`
+	ValidTo   *time.Time  `json:"validTo,omitempty"`
+}
+
+// ReconcileReport lists the repairs made, or to be made on a dry run, by
+// ReconcileReportingLines.
+type ReconcileReport struct {
+	DryRun          bool             `json:"dryRun"`
+	ClearedManagers []ClearedManager `json:"clearedManagers"`
+	ReporteeFixes   []ReporteeFix    `json:"reporteeFixes"`
+	LineFixes       []LineFix        `json:"lineFixes"`
+}
+
+type ClearedManager struct {
+	UserID    string `json:"userId"`
+	ManagerID string `json:"managerId"`
+	Reason    string `json:"reason"`
+}
+
+type ReporteeFix struct {
+	ManagerID string   `json:"managerId"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// LineFix is a reporting line record opened or closed to match the user's reportsTo.
+type LineFix struct {
+	UserID    string `json:"userId"`
+	ManagerID string `json:"managerId"`
+	Action    string `json:"action"`
 }
 
 // ---------------------------------------------------------------------------------------------------
 // ------------------------------------------ MONGO OBJECTS ------------------------------------------
 // ---------------------------------------------------------------------------------------------------
 type User struct {
-	ID        primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty" validate:"required"`
-	Password  string               `json:"-" bson:"password,omitempty" validate:"required"`
-	Email     string               `json:"email" bson:"email" validate:"required,email"`
-	FirstName string               `json:"firstName,omitempty" bson:"firstName,omitempty"`
-	LastName  string               `json:"lastName,omitempty" bson:"lastName,omitempty"`
-	ReportsTo *primitive.ObjectID  `json:"reportsTo" bson:"reportsTo,omitempty"`
-	Reportees []primitive.ObjectID `json:"reportees" bson:"reportees,omitempty"`
-	CreatedAt primitive.DateTime   `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
-	UpdatedAt primitive.DateTime   `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	ID              primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty" validate:"required"`
+	OrganisationID  primitive.ObjectID   `json:"organisationId" bson:"organisationId,omitempty"`
+	Password        string               `json:"-" bson:"password,omitempty" validate:"required"`
+	PasswordHistory []string             `json:"-" bson:"passwordHistory,omitempty"`
+	Email           string               `json:"email" bson:"email" validate:"required,email"`
+	EmailVerified   bool                 `json:"emailVerified" bson:"emailVerified"`
+	EmailVerifiedAt *primitive.DateTime  `json:"emailVerifiedAt,omitempty" bson:"emailVerifiedAt,omitempty"`
+	FirstName       string               `json:"firstName,omitempty" bson:"firstName,omitempty"`
+	LastName        string               `json:"lastName,omitempty" bson:"lastName,omitempty"`
+	JobTitle        string               `json:"jobTitle,omitempty" bson:"jobTitle,omitempty"`
+	Department      string               `json:"department,omitempty" bson:"department,omitempty"`
+	Location        string               `json:"location,omitempty" bson:"location,omitempty"`
+	Timezone        string               `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Locale          string               `json:"locale,omitempty" bson:"locale,omitempty"`
+	Pronouns        string               `json:"pronouns,omitempty" bson:"pronouns,omitempty"`
+	Avatar          *Avatar              `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	Roles           []string             `json:"roles" bson:"roles,omitempty"`
+	OIDCSubject     string               `json:"-" bson:"oidcSubject,omitempty"`
+	ExternalID      string               `json:"-" bson:"externalId,omitempty"`  // ID of the user in the SCIM client that provisions it
+	DirectoryDN     string               `json:"-" bson:"directoryDn,omitempty"` // DN of the LDAP entry the directory sync manages the user from
+	DeactivatedAt   *primitive.DateTime  `json:"-" bson:"deactivatedAt,omitempty"`
+	MFA             *MFASettings         `json:"-" bson:"mfa,omitempty"`
+	ReportsTo       *primitive.ObjectID  `json:"reportsTo" bson:"reportsTo,omitempty"`
+	Reportees       []primitive.ObjectID `json:"reportees" bson:"reportees,omitempty"`
+	CreatedAt       primitive.DateTime   `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt       primitive.DateTime   `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// UserSummary is the part of a user shown in the org chart and next to weekly reports.
+type UserSummary struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Email     string             `json:"email" bson:"email" validate:"required"`
+	FirstName string             `json:"firstName" bson:"firstName" validate:"required"`
+	LastName  string             `json:"lastName" bson:"lastName" validate:"required"`
+	Avatar    *Avatar            `json:"avatar,omitempty" bson:"avatar,omitempty"`
+}
+
+// Avatar is the profile picture of a user, stored once for every size of AvatarSizes. The
+// URLs are kept as they were when it was uploaded, the keys let the images be deleted.
+type Avatar struct {
+	URLs      map[string]string  `json:"urls" bson:"urls"`
+	Keys      map[string]string  `json:"-" bson:"keys"`
+	UpdatedAt primitive.DateTime `json:"-" bson:"updatedAt"`
+}
+
+// OrgChartEntry is a user found while walking the reporting lines, Depth levels away from the
+// user the walk started from.
+type OrgChartEntry struct {
+	UserSummary `bson:",inline"`
+	ReportsTo   *primitive.ObjectID `bson:"reportsTo,omitempty"`
+	Depth       int                 `bson:"depth"`
+}
+
+// ReportingLine records that the user reported to the manager from ValidFrom until ValidTo.
+// The current reporting line of a user has no ValidTo, and reportsTo on the user mirrors it.
+type ReportingLine struct {
+	ID             primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganisationID primitive.ObjectID  `json:"-" bson:"organisationId,omitempty"`
+	UserID         primitive.ObjectID  `json:"userId" bson:"userId"`
+	ManagerID      primitive.ObjectID  `json:"managerId" bson:"managerId"`
+	ValidFrom      primitive.DateTime  `json:"validFrom" bson:"validFrom"`
+	ValidTo        *primitive.DateTime `json:"validTo,omitempty" bson:"validTo,omitempty"`
+}
+
+// MFASettings holds the TOTP second factor of a user. PendingSecret is set during enrollment
+// until the user proves their authenticator app works by submitting a first code.
+type MFASettings struct {
+	Enabled       bool                `bson:"enabled"`
+	Secret        string              `bson:"secret,omitempty"`
+	PendingSecret string              `bson:"pendingSecret,omitempty"`
+	RecoveryCodes []string            `bson:"recoveryCodes,omitempty"`
+	LastUsedStep  int64               `bson:"lastUsedStep,omitempty"`
+	EnabledAt     *primitive.DateTime `bson:"enabledAt,omitempty"`
+}
+
+// Active reports whether the user may log in. Deactivated users are kept so that their one-to-ones
+// stay attributed.
+func (u User) Active() bool {
+	return u.DeactivatedAt == nil
+}
+
+// MFAEnabled reports whether the user has completed TOTP enrollment.
+func (u User) MFAEnabled() bool {
+	return u.MFA != nil && u.MFA.Enabled
+}
+
+// EffectiveRoles returns the roles of the user. Accounts created before roles existed have
+// none stored and are treated as employees.
+func (u User) EffectiveRoles() []string {
+	if len(u.Roles) == 0 {
+		return []string{auth.RoleEmployee}
+	}
+	return u.Roles
+}
+
+// UserFilter narrows down GetUsers. Empty fields match every user.
+type UserFilter struct {
+	ID         *primitive.ObjectID
+	Email      string
+	ExternalID string
+	Active     *bool
 }
@@ -0,0 +1,145 @@
+package user
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultOrgChartDepth = 3
+	maxOrgChartDepth     = 20
+)
+
+// Modes of the org chart.
+const (
+	OrgChartModeTree      = "tree"
+	OrgChartModeAncestors = "ancestors"
+)
+
+// @Summary Get the org chart
+// @Description Get the reporting tree rooted at a user, or in ancestors mode the chain of managers from the user's manager up to the top of the organisation
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param mode query string false "tree (default) or ancestors"
+// @Param depth query int false "Number of levels below the user in tree mode, 3 by default, or above the user in ancestors mode, all by default. At most 20."
+// @Param asOf query string false "Date (2006-01-02) or time (RFC 3339) to show the reporting lines as they were at, now by default"
+// @Success 200 {object} OrgChartResponse "Org chart retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID, mode, depth or date"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/{id}/org-chart [get]
+func (h *UserHandler) GetOrgChart(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	mode := c.DefaultQuery("mode", OrgChartModeTree)
+	if mode != OrgChartModeTree && mode != OrgChartModeAncestors {
+		api.Error(c, http.StatusBadRequest, "Invalid mode", nil)
+		return
+	}
+
+	depth := defaultOrgChartDepth
+	if mode == OrgChartModeAncestors {
+		depth = maxOrgChartDepth
+	}
+	if depthStr := c.Query("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 1 || depth > maxOrgChartDepth {
+			api.Error(c, http.StatusBadRequest, "Invalid depth", nil)
+			return
+		}
+	}
+
+	var asOf *time.Time
+	if asOfStr := c.Query("asOf"); asOfStr != "" {
+		parsed, err := parseAsOf(asOfStr)
+		if err != nil {
+			api.Error(c, http.StatusBadRequest, "Invalid date", nil)
+			return
+		}
+		asOf = &parsed
+	}
+
+	var root UserSummary
+	var entries []OrgChartEntry
+	switch {
+	case mode == OrgChartModeTree && asOf == nil:
+		root, entries, err = h.Repo.GetReportingTree(c.Request.Context(), userID, depth)
+	case mode == OrgChartModeTree:
+		root, entries, err = h.Repo.GetReportingTreeAsOf(c.Request.Context(), userID, depth, *asOf)
+	case asOf == nil:
+		_, entries, err = h.Repo.GetReportingChain(c.Request.Context(), userID, depth)
+	default:
+		_, entries, err = h.Repo.GetReportingChainAsOf(c.Request.Context(), userID, depth, *asOf)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	var response OrgChartResponse
+	if mode == OrgChartModeTree {
+		tree := BuildOrgChart(root, entries)
+		response.Tree = &tree
+	} else {
+		response.Ancestors = ConvertToAncestors(entries)
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved org chart successfully", response)
+}
+
+// @Summary Get the manager history of a user
+// @Description Get the managers a user has reported to and when, the current manager first
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} ManagerHistoryEntry "Manager history retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/{id}/managers [get]
+func (h *UserHandler) GetManagerHistory(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	lines, managers, err := h.Repo.GetManagerHistory(c.Request.Context(), userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved manager history successfully", ConvertToManagerHistory(lines, managers))
+}
+
+// parseAsOf reads a date, taken as its start in UTC, or a time in RFC 3339.
+func parseAsOf(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
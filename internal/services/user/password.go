@@ -0,0 +1,116 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2id parameters, following the OWASP recommendation for a memory bound of 19 MiB.
+// Hashes made with other parameters are upgraded on the next login.
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+const argon2Prefix = "$argon2id$"
+
+// dummyPasswordHash is compared against when a login matches no password, so that unknown
+// emails take as long to reject as wrong passwords.
+var dummyPasswordHash, _ = HashPassword("one-to-one dummy password")
+
+// HashPassword hashes a plain text password for storage. The hash is in the PHC string format,
+// $argon2id$v=19$m=...,t=...,p=...$salt$key.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword reports whether password is the password of user. user may be nil, or have no
+// password when it was provisioned by an identity provider, in which case a hash is still
+// compared to keep the timing uniform.
+func CheckPassword(user *User, password string) bool {
+	if user == nil || user.Password == "" {
+		comparePassword(dummyPasswordHash, password)
+		return false
+	}
+
+	return comparePassword(user.Password, password)
+}
+
+// PasswordNeedsRehash reports whether a stored hash should be replaced by one made with
+// HashPassword, because it is a bcrypt hash or uses outdated Argon2id parameters.
+func PasswordNeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params != [3]uint32{argon2Memory, argon2Time, argon2Threads}
+}
+
+// comparePassword checks a password against an Argon2id or a legacy bcrypt hash.
+func comparePassword(hash string, password string) bool {
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params[1], params[0], uint8(params[2]), uint32(len(key)))
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
+
+// decodeArgon2Hash splits a PHC string into its memory, time and threads parameters, the salt
+// and the key.
+func decodeArgon2Hash(hash string) ([3]uint32, []byte, []byte, error) {
+	var params [3]uint32
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params[0], &params[1], &params[2]); err != nil {
+		return params, nil, nil, err
+	}
+	if params[2] == 0 || params[2] > 255 {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parallelism")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	return params, salt, key, nil
+}
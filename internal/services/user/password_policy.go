@@ -0,0 +1,160 @@
+package user
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"one-to-one/internal/config"
+)
+
+// maxPasswordLength bounds the work a single login can cause.
+const maxPasswordLength = 128
+
+// PasswordPolicyError is returned when a new password is rejected by the password policy. Its
+// message is meant to be shown to the user.
+type PasswordPolicyError struct {
+	Message string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Message
+}
+
+var (
+	breachedOnce      sync.Once
+	breachedPasswords map[string]struct{}
+	breachedErr       error
+)
+
+// CheckPasswordPolicy validates a new password for user, which is nil on sign-up. Violations
+// are reported as *PasswordPolicyError, any other error means the policy could not be checked.
+func CheckPasswordPolicy(user *User, password string) error {
+	authConfig := config.AppConfig().Auth
+
+	if len(password) < authConfig.PasswordMinLength {
+		return &PasswordPolicyError{fmt.Sprintf("Password must be at least %d characters long", authConfig.PasswordMinLength)}
+	}
+	if len(password) > maxPasswordLength {
+		return &PasswordPolicyError{fmt.Sprintf("Password must be at most %d characters long", maxPasswordLength)}
+	}
+
+	breached, err := isBreachedPassword(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return &PasswordPolicyError{"This password has appeared in a data breach, choose a different one"}
+	}
+
+	if user != nil {
+		for _, hash := range recentPasswordHashes(*user) {
+			if comparePassword(hash, password) {
+				return &PasswordPolicyError{"Password was used recently, choose a different one"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsPasswordPolicyError reports whether err is a password policy violation.
+func IsPasswordPolicyError(err error) bool {
+	var policyErr *PasswordPolicyError
+	return errors.As(err, &policyErr)
+}
+
+// PasswordHistoryAfterChange returns the hashes to keep in the user's password history once
+// the current password is replaced.
+func PasswordHistoryAfterChange(user User) []string {
+	keep := config.AppConfig().Auth.PasswordHistory - 1
+	if keep <= 0 {
+		return []string{}
+	}
+
+	history := recentPasswordHashes(user)
+	if len(history) > keep {
+		history = history[:keep]
+	}
+	return history
+}
+
+// recentPasswordHashes returns the current password hash followed by the previous ones, most
+// recent first, limited to the configured history.
+func recentPasswordHashes(user User) []string {
+	hashes := []string{}
+	if user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+	hashes = append(hashes, user.PasswordHistory...)
+
+	if limit := config.AppConfig().Auth.PasswordHistory; len(hashes) > limit {
+		hashes = hashes[:limit]
+	}
+	return hashes
+}
+
+// isBreachedPassword looks the password up in the breached password file. The file holds one
+// entry per line, either the password itself or its SHA-1 in hex as distributed by Have I Been
+// Pwned, optionally followed by ":count".
+func isBreachedPassword(password string) (bool, error) {
+	breachedOnce.Do(func() {
+		breachedPasswords, breachedErr = loadBreachedPasswords(config.AppConfig().Auth.BreachedPasswordsFile)
+	})
+	if breachedErr != nil {
+		return false, breachedErr
+	}
+
+	_, found := breachedPasswords[sha1Hex(password)]
+	return found, nil
+}
+
+func loadBreachedPasswords(path string) (map[string]struct{}, error) {
+	passwords := map[string]struct{}{}
+	if path == "" {
+		return passwords, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			passwords[strings.ToUpper(hash)] = struct{}{}
+		} else {
+			passwords[sha1Hex(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+
+	return passwords, nil
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1Hex(value string) bool {
+	if len(value) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
@@ -0,0 +1,266 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/config"
+	"one-to-one/internal/mailer"
+	"one-to-one/internal/services/auth"
+)
+
+// resetUserRepo serves a single user from memory. Methods the reset flow does not use are left
+// to the embedded nil interface.
+type resetUserRepo struct {
+	UserRepository
+	user *User
+}
+
+func (r *resetUserRepo) GetUserByEmail(c context.Context, email string) (*User, error) {
+	if r.user == nil || !strings.EqualFold(r.user.Email, email) {
+		return nil, mongo.ErrNoDocuments
+	}
+	account := *r.user
+	return &account, nil
+}
+
+func (r *resetUserRepo) GetUserByID(c context.Context, id primitive.ObjectID) (*User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	account := *r.user
+	return &account, nil
+}
+
+func (r *resetUserRepo) UpdatePassword(c context.Context, userID primitive.ObjectID, password string, history []string) error {
+	r.user.Password = password
+	r.user.PasswordHistory = history
+	return nil
+}
+
+// resetAuthRepo keeps reset tokens in memory with the same single-use and expiry rules as the
+// MongoDB repository.
+type resetAuthRepo struct {
+	auth.AuthRepository
+	tokens  []auth.PasswordResetToken
+	revoked []primitive.ObjectID
+}
+
+func (r *resetAuthRepo) CreatePasswordResetToken(c context.Context, token auth.PasswordResetToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *resetAuthRepo) find(tokenHash string) *auth.PasswordResetToken {
+	now := time.Now()
+	for i := range r.tokens {
+		token := &r.tokens[i]
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.Time().After(now) {
+			return token
+		}
+	}
+	return nil
+}
+
+func (r *resetAuthRepo) GetPasswordResetToken(c context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	token := r.find(tokenHash)
+	if token == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	found := *token
+	return &found, nil
+}
+
+func (r *resetAuthRepo) ConsumePasswordResetToken(c context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	token := r.find(tokenHash)
+	if token == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	token.UsedAt = &now
+	found := *token
+	return &found, nil
+}
+
+func (r *resetAuthRepo) RevokeAllUserTokens(c context.Context, userID primitive.ObjectID) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type resetFixture struct {
+	handler  *UserHandler
+	users    *resetUserRepo
+	authRepo *resetAuthRepo
+	mail     *mailer.MemoryMailer
+	router   *gin.Engine
+}
+
+func newResetFixture(t *testing.T) *resetFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	config.AppConfig().App.ClientURL = "https://app.example.com"
+	config.AppConfig().Auth.ResetTokenExpire = 30
+	config.AppConfig().Auth.PasswordMinLength = 8
+	config.AppConfig().Auth.PasswordHistory = 2
+
+	hashed, err := HashPassword("old password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := NewProvisionedUser("ada@example.com", "Ada", "Lovelace")
+	account.Password = hashed
+
+	f := &resetFixture{
+		users:    &resetUserRepo{user: &account},
+		authRepo: &resetAuthRepo{},
+		mail:     mailer.NewMemoryMailer(),
+		router:   gin.New(),
+	}
+	f.handler = NewUserHandler(f.users, f.authRepo, nil, nil, f.mail, nil)
+	f.router.POST("/user/password/forgot", f.handler.ForgotPassword)
+	f.router.POST("/user/password/reset", f.handler.ResetPassword)
+	return f
+}
+
+func (f *resetFixture) post(t *testing.T, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// resetToken requests a reset for email and returns the token from the link in the email.
+func (f *resetFixture) resetToken(t *testing.T, email string) string {
+	t.Helper()
+
+	f.mail.Reset()
+	if w := f.post(t, "/user/password/forgot", ForgotPasswordRequest{Email: email}); w.Code != http.StatusOK {
+		t.Fatalf("forgot password answered %d: %s", w.Code, w.Body)
+	}
+	messages := f.mail.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(messages))
+	}
+
+	for _, field := range strings.Fields(messages[0].Body) {
+		if strings.HasPrefix(field, "https://app.example.com/reset-password?") {
+			link, err := url.Parse(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no reset link in the email:\n%s", messages[0].Body)
+	return ""
+}
+
+func TestForgotPasswordEmailsResetLink(t *testing.T) {
+	f := newResetFixture(t)
+
+	token := f.resetToken(t, "ADA@example.com")
+
+	msg := f.mail.Messages()[0]
+	if msg.To != "ada@example.com" || msg.Subject != "Reset your OneToOne password" {
+		t.Errorf("sent %q to %q", msg.Subject, msg.To)
+	}
+	if !strings.Contains(msg.Body, "expires in 30 minutes") {
+		t.Errorf("the email does not say when the link expires:\n%s", msg.Body)
+	}
+	if token == "" || len(f.authRepo.tokens) != 1 || f.authRepo.tokens[0].TokenHash != auth.HashToken(token) {
+		t.Errorf("the emailed token is not the stored one")
+	}
+}
+
+func TestForgotPasswordDoesNotRevealAccounts(t *testing.T) {
+	f := newResetFixture(t)
+	known := f.post(t, "/user/password/forgot", ForgotPasswordRequest{Email: "ada@example.com"})
+
+	f.mail.Reset()
+	unknown := f.post(t, "/user/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"})
+	if unknown.Code != known.Code || unknown.Body.String() != known.Body.String() {
+		t.Errorf("unknown email answered %d %s, known email %d %s", unknown.Code, unknown.Body, known.Code, known.Body)
+	}
+	if len(f.mail.Messages()) != 0 {
+		t.Errorf("an email was sent for an unknown address")
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	f.users.user.DeactivatedAt = &now
+	deactivated := f.post(t, "/user/password/forgot", ForgotPasswordRequest{Email: "ada@example.com"})
+	if deactivated.Code != known.Code || deactivated.Body.String() != known.Body.String() {
+		t.Errorf("deactivated account answered %d %s", deactivated.Code, deactivated.Body)
+	}
+	if len(f.mail.Messages()) != 0 {
+		t.Errorf("an email was sent to a deactivated account")
+	}
+}
+
+func TestResetPasswordWithEmailedToken(t *testing.T) {
+	f := newResetFixture(t)
+	token := f.resetToken(t, "ada@example.com")
+	oldHash := f.users.user.Password
+
+	w := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: token, Password: "new password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset answered %d: %s", w.Code, w.Body)
+	}
+
+	if !CheckPassword(f.users.user, "new password") {
+		t.Errorf("the new password was not stored")
+	}
+	if len(f.users.user.PasswordHistory) != 1 || f.users.user.PasswordHistory[0] != oldHash {
+		t.Errorf("the old password was not kept in the history")
+	}
+	if len(f.authRepo.revoked) != 1 || f.authRepo.revoked[0] != f.users.user.ID {
+		t.Errorf("existing sessions were not logged out")
+	}
+
+	again := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: token, Password: "another password"})
+	if again.Code != http.StatusBadRequest {
+		t.Errorf("reusing the token answered %d, want 400", again.Code)
+	}
+}
+
+func TestResetPasswordRejections(t *testing.T) {
+	f := newResetFixture(t)
+	token := f.resetToken(t, "ada@example.com")
+
+	if w := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: "not-a-token", Password: "new password"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown token answered %d, want 400", w.Code)
+	}
+
+	// A password rejected by the policy leaves the token usable.
+	for _, password := range []string{"short", "old password"} {
+		if w := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: token, Password: password}); w.Code != http.StatusBadRequest {
+			t.Errorf("password %q answered %d, want 400", password, w.Code)
+		}
+	}
+	if w := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: token, Password: "new password"}); w.Code != http.StatusOK {
+		t.Errorf("reset after rejected passwords answered %d: %s", w.Code, w.Body)
+	}
+
+	expired := f.resetToken(t, "ada@example.com")
+	f.authRepo.tokens[len(f.authRepo.tokens)-1].ExpiresAt = primitive.NewDateTimeFromTime(time.Now().Add(-time.Minute))
+	if w := f.post(t, "/user/password/reset", ResetPasswordRequest{Token: expired, Password: "newer password"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expired token answered %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package user
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+	"one-to-one/internal/services/auth"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// personalAccessTokenPrefixLength is how much of a token is stored in clear so users can tell
+// their tokens apart.
+const personalAccessTokenPrefixLength = 8
+
+// @Summary Create a personal access token
+// @Description Create a token for scripts and integrations. The token is only returned in this response.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body CreatePersonalAccessTokenRequest true "Token name, scopes and lifetime"
+// @Success 201 {object} CreatedPersonalAccessTokenResponse "Token created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or parameters"
+// @Failure 403 {object} map[string]interface{} "Two-factor authentication is required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/tokens [post]
+func (h *UserHandler) CreatePersonalAccessToken(c *gin.Context) {
+	var reqPayload CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&reqPayload); err != nil {
+		api.Error(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, "An error occurred while processing your request", nil)
+		return
+	}
+	token := auth.PersonalAccessTokenPrefix + secret
+
+	now := time.Now()
+	pat := auth.PersonalAccessToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      reqPayload.Name,
+		Prefix:    token[:len(auth.PersonalAccessTokenPrefix)+personalAccessTokenPrefixLength],
+		TokenHash: auth.HashToken(token),
+		Scopes:    reqPayload.Scopes,
+		MFA:       c.GetBool("mfa"),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+	if reqPayload.ExpiresInDays != nil {
+		expiresAt := primitive.NewDateTimeFromTime(now.AddDate(0, 0, *reqPayload.ExpiresInDays))
+		pat.ExpiresAt = &expiresAt
+	}
+
+	if err := h.AuthRepo.CreatePersonalAccessToken(c.Request.Context(), pat); err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusCreated, "Created personal access token successfully", CreatedPersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: ConvertToPersonalAccessTokenResponse(pat),
+		Token:                       token,
+	})
+}
+
+// @Summary List personal access tokens
+// @Description List the personal access tokens of the current user, including revoked and expired ones
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {array} PersonalAccessTokenResponse "Tokens retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Two-factor authentication is required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/tokens [get]
+func (h *UserHandler) GetPersonalAccessTokens(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	tokens, err := h.AuthRepo.GetPersonalAccessTokens(c.Request.Context(), userID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	response := make([]PersonalAccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = ConvertToPersonalAccessTokenResponse(token)
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved personal access tokens successfully", response)
+}
+
+// @Summary Revoke a personal access token
+// @Description Revoke one of the current user's personal access tokens. It stops working immediately.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "Token ID"
+// @Success 200 {object} map[string]interface{} "Token revoked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid token ID"
+// @Failure 403 {object} map[string]interface{} "Two-factor authentication is required"
+// @Failure 404 {object} map[string]interface{} "Token not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/tokens/{id} [delete]
+func (h *UserHandler) RevokePersonalAccessToken(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid token ID", nil)
+		return
+	}
+
+	if err := h.AuthRepo.RevokePersonalAccessToken(c.Request.Context(), userID, tokenID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "Token not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Revoked personal access token successfully", nil)
+}
@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/services/organisation"
+)
+
+var (
+	ErrSelfManager     = errors.New("a user cannot be their own manager")
+	ErrReportingCycle  = errors.New("the manager already reports to this user")
+	ErrManagerNotFound = errors.New("manager not found")
+)
+
+// Actions on the reporting line records taken by the reconciliation.
+const (
+	lineClosed = "closed"
+	lineOpened = "opened"
+)
+
+// Reasons a reporting line is cleared by the reconciliation.
+const (
+	clearedSelfManager    = "self-manager"
+	clearedUnknownManager = "unknown-manager"
+	clearedCycle          = "cycle"
+)
+
+// AssignDefaultManager makes a newly created user report to the default manager of the
+// organisation. Without a default manager, or before the organisation is set up, the user stays
+// unassigned.
+func AssignDefaultManager(c context.Context, repo UserRepository, orgRepo organisation.OrganisationRepository, account *User) error {
+	org, err := orgRepo.GetOrganisation(c)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if org.DefaultManagerID == nil || *org.DefaultManagerID == account.ID {
+		return nil
+	}
+
+	if err := repo.SetManager(c, account.ID, org.DefaultManagerID); err != nil {
+		return err
+	}
+	account.ReportsTo = org.DefaultManagerID
+
+	return nil
+}
+
+// IsReportingLineError reports whether err rejects a new reporting line, as opposed to a
+// failure to store it.
+func IsReportingLineError(err error) bool {
+	return err == ErrSelfManager || err == ErrReportingCycle || err == ErrManagerNotFound
+}
+
+// planReconciliation works out the repairs to the reporting lines, treating each user's
+// reportsTo as the source of truth:
+//  1. A user who reports to themselves, to a user that does not exist or to a user of another
+//     organisation reports to nobody.
+//  2. In a cycle, the user with the lowest ID stops reporting to anyone, which makes them the
+//     top of what was the cycle.
+//  3. Every manager's reportees are rebuilt from the reportsTo of the other users.
+//
+// It also returns the reportees each manager should end up with.
+func planReconciliation(users []User) (ReconcileReport, map[primitive.ObjectID][]primitive.ObjectID) {
+	report := ReconcileReport{ClearedManagers: []ClearedManager{}, ReporteeFixes: []ReporteeFix{}}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID.Hex() < users[j].ID.Hex() })
+
+	organisationOf := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, u := range users {
+		organisationOf[u.ID] = u.OrganisationID
+	}
+
+	reportsTo := map[primitive.ObjectID]primitive.ObjectID{}
+	clear := func(userID primitive.ObjectID, reason string) {
+		report.ClearedManagers = append(report.ClearedManagers, ClearedManager{
+			UserID:    userID.Hex(),
+			ManagerID: reportsTo[userID].Hex(),
+			Reason:    reason,
+		})
+		delete(reportsTo, userID)
+	}
+
+	for _, u := range users {
+		if u.ReportsTo == nil {
+			continue
+		}
+		reportsTo[u.ID] = *u.ReportsTo
+
+		if *u.ReportsTo == u.ID {
+			clear(u.ID, clearedSelfManager)
+		} else if managerOrg, ok := organisationOf[*u.ReportsTo]; !ok || managerOrg != u.OrganisationID {
+			clear(u.ID, clearedUnknownManager)
+		}
+	}
+
+	// Walk up from every user. Reaching a user that is on the current path closes a cycle,
+	// reaching one that was walked from before does not.
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := map[primitive.ObjectID]int{}
+	for _, u := range users {
+		path := []primitive.ObjectID{}
+		current, ok := u.ID, true
+		for ok && state[current] == unvisited {
+			state[current] = onPath
+			path = append(path, current)
+			current, ok = reportsTo[current]
+		}
+
+		if ok && state[current] == onPath {
+			lowest := current
+			for next := reportsTo[current]; next != current; next = reportsTo[next] {
+				if next.Hex() < lowest.Hex() {
+					lowest = next
+				}
+			}
+			clear(lowest, clearedCycle)
+		}
+
+		for _, id := range path {
+			state[id] = done
+		}
+	}
+
+	expected := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, u := range users {
+		if managerID, ok := reportsTo[u.ID]; ok {
+			expected[managerID] = append(expected[managerID], u.ID)
+		}
+	}
+
+	for _, u := range users {
+		fix := ReporteeFix{ManagerID: u.ID.Hex(), Added: []string{}, Removed: []string{}}
+
+		current := map[primitive.ObjectID]bool{}
+		for _, id := range u.Reportees {
+			current[id] = true
+		}
+		wanted := map[primitive.ObjectID]bool{}
+		for _, id := range expected[u.ID] {
+			wanted[id] = true
+			if !current[id] {
+				fix.Added = append(fix.Added, id.Hex())
+			}
+		}
+		for id := range current {
+			if !wanted[id] {
+				fix.Removed = append(fix.Removed, id.Hex())
+			}
+		}
+		sort.Strings(fix.Removed)
+
+		// Duplicates are dropped too, even when the set of reportees is right.
+		if len(fix.Added) == 0 && len(fix.Removed) == 0 && len(u.Reportees) == len(expected[u.ID]) {
+			continue
+		}
+		if expected[u.ID] == nil {
+			expected[u.ID] = []primitive.ObjectID{}
+		}
+		report.ReporteeFixes = append(report.ReporteeFixes, fix)
+	}
+
+	return report, expected
+}
+
+// planLineFixes works out the reporting line records to close and open so that every user has
+// one current record exactly when they report to someone once the reconciliation is done, and
+// for the manager they report to. A user without any record is taken to have reported to their
+// manager since they were created, which backfills the records of reporting lines set before
+// they were kept. The other opened records have no ValidFrom yet; they start when the
+// reconciliation is applied.
+func planLineFixes(users []User, expected map[primitive.ObjectID][]primitive.ObjectID, openLines []ReportingLine, withHistory map[primitive.ObjectID]bool) ([]ReportingLine, []ReportingLine) {
+	reportsTo := map[primitive.ObjectID]primitive.ObjectID{}
+	for managerID, reporteeIDs := range expected {
+		for _, id := range reporteeIDs {
+			reportsTo[id] = managerID
+		}
+	}
+
+	current := map[primitive.ObjectID][]ReportingLine{}
+	for _, line := range openLines {
+		current[line.UserID] = append(current[line.UserID], line)
+	}
+
+	closed, opened := []ReportingLine{}, []ReportingLine{}
+	for _, u := range users {
+		managerID, reports := reportsTo[u.ID]
+
+		kept := false
+		for _, line := range current[u.ID] {
+			if reports && !kept && line.ManagerID == managerID {
+				kept = true
+				continue
+			}
+			closed = append(closed, line)
+		}
+
+		if reports && !kept {
+			line := ReportingLine{
+				ID:             primitive.NewObjectID(),
+				OrganisationID: u.OrganisationID,
+				UserID:         u.ID,
+				ManagerID:      managerID,
+			}
+			if !withHistory[u.ID] {
+				line.ValidFrom = u.CreatedAt
+			}
+			opened = append(opened, line)
+		}
+	}
+
+	return closed, opened
+}
+
+// lineFixes lists the records closed and opened by the reconciliation for its report.
+func lineFixes(closed []ReportingLine, opened []ReportingLine) []LineFix {
+	fixes := []LineFix{}
+	for _, line := range closed {
+		fixes = append(fixes, LineFix{UserID: line.UserID.Hex(), ManagerID: line.ManagerID.Hex(), Action: lineClosed})
+	}
+	for _, line := range opened {
+		fixes = append(fixes, LineFix{UserID: line.UserID.Hex(), ManagerID: line.ManagerID.Hex(), Action: lineOpened})
+	}
+	return fixes
+}
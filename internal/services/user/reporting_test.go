@@ -0,0 +1,162 @@
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testID returns an ObjectID that sorts by n, so tests can tell which user has the lowest ID.
+func testID(n byte) primitive.ObjectID {
+	var id primitive.ObjectID
+	id[len(id)-1] = n
+	return id
+}
+
+func testMember(id byte, org byte, reportsTo *byte, reportees ...byte) User {
+	u := User{ID: testID(id), OrganisationID: testID(100 + org)}
+	if reportsTo != nil {
+		managerID := testID(*reportsTo)
+		u.ReportsTo = &managerID
+	}
+	for _, reportee := range reportees {
+		u.Reportees = append(u.Reportees, testID(reportee))
+	}
+	return u
+}
+
+func reportsToID(id byte) *byte {
+	return &id
+}
+
+func testHexes(ids ...byte) []string {
+	out := []string{}
+	for _, id := range ids {
+		out = append(out, testID(id).Hex())
+	}
+	return out
+}
+
+func TestPlanReconciliation(t *testing.T) {
+	tests := []struct {
+		name          string
+		users         []User
+		wantCleared   []ClearedManager
+		wantFixes     []ReporteeFix
+		wantReportees map[byte][]byte
+	}{
+		{
+			name:          "consistent lines are left alone",
+			users:         []User{testMember(1, 1, nil, 2), testMember(2, 1, reportsToID(1))},
+			wantCleared:   []ClearedManager{},
+			wantFixes:     []ReporteeFix{},
+			wantReportees: map[byte][]byte{1: {2}},
+		},
+		{
+			name:  "self-management",
+			users: []User{testMember(1, 1, reportsToID(1), 1)},
+			wantCleared: []ClearedManager{
+				{UserID: testID(1).Hex(), ManagerID: testID(1).Hex(), Reason: clearedSelfManager},
+			},
+			wantFixes: []ReporteeFix{
+				{ManagerID: testID(1).Hex(), Added: []string{}, Removed: testHexes(1)},
+			},
+			wantReportees: map[byte][]byte{},
+		},
+		{
+			name:  "unknown manager",
+			users: []User{testMember(1, 1, reportsToID(9))},
+			wantCleared: []ClearedManager{
+				{UserID: testID(1).Hex(), ManagerID: testID(9).Hex(), Reason: clearedUnknownManager},
+			},
+			wantFixes:     []ReporteeFix{},
+			wantReportees: map[byte][]byte{},
+		},
+		{
+			name:  "manager in another organisation",
+			users: []User{testMember(1, 1, reportsToID(2)), testMember(2, 2, nil, 1)},
+			wantCleared: []ClearedManager{
+				{UserID: testID(1).Hex(), ManagerID: testID(2).Hex(), Reason: clearedUnknownManager},
+			},
+			wantFixes: []ReporteeFix{
+				{ManagerID: testID(2).Hex(), Added: []string{}, Removed: testHexes(1)},
+			},
+			wantReportees: map[byte][]byte{},
+		},
+		{
+			name:  "two user cycle",
+			users: []User{testMember(2, 1, reportsToID(1), 1), testMember(1, 1, reportsToID(2), 2)},
+			wantCleared: []ClearedManager{
+				{UserID: testID(1).Hex(), ManagerID: testID(2).Hex(), Reason: clearedCycle},
+			},
+			wantFixes: []ReporteeFix{
+				{ManagerID: testID(2).Hex(), Added: []string{}, Removed: testHexes(1)},
+			},
+			wantReportees: map[byte][]byte{1: {2}},
+		},
+		{
+			// 4 reports into the cycle without being part of it.
+			name: "three user cycle",
+			users: []User{
+				testMember(4, 1, reportsToID(3)),
+				testMember(1, 1, reportsToID(2), 3),
+				testMember(2, 1, reportsToID(3), 1),
+				testMember(3, 1, reportsToID(1), 2, 4),
+			},
+			wantCleared: []ClearedManager{
+				{UserID: testID(1).Hex(), ManagerID: testID(2).Hex(), Reason: clearedCycle},
+			},
+			wantFixes: []ReporteeFix{
+				{ManagerID: testID(2).Hex(), Added: []string{}, Removed: testHexes(1)},
+			},
+			wantReportees: map[byte][]byte{1: {3}, 3: {2, 4}},
+		},
+		{
+			name: "reportees rebuilt from reportsTo",
+			users: []User{
+				testMember(1, 1, nil, 2, 4, 2),
+				testMember(2, 1, reportsToID(1)),
+				testMember(3, 1, reportsToID(1)),
+				testMember(4, 1, nil),
+				testMember(5, 1, reportsToID(4)),
+			},
+			wantCleared: []ClearedManager{},
+			wantFixes: []ReporteeFix{
+				{ManagerID: testID(1).Hex(), Added: testHexes(3), Removed: testHexes(4)},
+				{ManagerID: testID(4).Hex(), Added: testHexes(5), Removed: []string{}},
+			},
+			wantReportees: map[byte][]byte{1: {2, 3}, 4: {5}},
+		},
+		{
+			name:          "duplicate reportees are dropped",
+			users:         []User{testMember(1, 1, nil, 2, 2), testMember(2, 1, reportsToID(1))},
+			wantCleared:   []ClearedManager{},
+			wantFixes:     []ReporteeFix{{ManagerID: testID(1).Hex(), Added: []string{}, Removed: []string{}}},
+			wantReportees: map[byte][]byte{1: {2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, expected := planReconciliation(tt.users)
+
+			if !reflect.DeepEqual(report.ClearedManagers, tt.wantCleared) {
+				t.Errorf("cleared managers %+v, want %+v", report.ClearedManagers, tt.wantCleared)
+			}
+			if !reflect.DeepEqual(report.ReporteeFixes, tt.wantFixes) {
+				t.Errorf("reportee fixes %+v, want %+v", report.ReporteeFixes, tt.wantFixes)
+			}
+
+			got := map[byte][]byte{}
+			for managerID, reporteeIDs := range expected {
+				for _, id := range reporteeIDs {
+					got[managerID[len(managerID)-1]] = append(got[managerID[len(managerID)-1]], id[len(id)-1])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantReportees) {
+				t.Errorf("reportees %v, want %v", got, tt.wantReportees)
+			}
+		})
+	}
+}
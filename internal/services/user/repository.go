@@ -3,38 +3,97 @@ package user
 import (
 	"context"
 	"errors"
+	"log"
+	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"one-to-one/internal/db"
+	"one-to-one/internal/tenant"
 )
 
 type UserRepository interface {
 	CreateUser(c context.Context, user User) (User, error)
+	DeleteUser(c context.Context, id primitive.ObjectID) error
 	GetAllUsers(c context.Context) ([]User, error)
 	GetUserByID(c context.Context, id primitive.ObjectID) (*User, error)
 	GetUserByEmail(c context.Context, email string) (*User, error)
-	
-	AddReportee(c context.Context, userID primitive.ObjectID, reporteeID primitive.ObjectID) error
-	RemoveReportee(c context.Context, userID primitive.ObjectID, reporteeID primitive.ObjectID) error
-	AddReportsTo(c context.Context, userID primitive.ObjectID, reportsToID primitive.ObjectID) error
+	GetUsers(c context.Context, filter UserFilter, skip int64, limit int64) ([]User, int64, error)
+	UpdateIdentity(c context.Context, userID primitive.ObjectID, email string, firstName string, lastName string, externalID string) error
+	SetActive(c context.Context, userID primitive.ObjectID, active bool) error
+	SetDirectoryDN(c context.Context, userID primitive.ObjectID, dn string) error
+	UpdatePassword(c context.Context, userID primitive.ObjectID, password string, history []string) error
+	RehashPassword(c context.Context, userID primitive.ObjectID, oldHash string, newHash string) error
+	MarkEmailVerified(c context.Context, userID primitive.ObjectID, email string) error
+	UpdateRoles(c context.Context, userID primitive.ObjectID, roles []string) (*User, error)
+	UpdateProfile(c context.Context, userID primitive.ObjectID, set bson.M, unset bson.M) (*User, error)
+	SetAvatar(c context.Context, userID primitive.ObjectID, avatar *Avatar) (*User, *Avatar, error)
+	GetUserByOIDCSubject(c context.Context, subject string) (*User, error)
+	SetOIDCSubject(c context.Context, userID primitive.ObjectID, subject string) error
+	UpdateMFA(c context.Context, userID primitive.ObjectID, mfa *MFASettings) error
+	RecordMFAStep(c context.Context, userID primitive.ObjectID, step int64) (bool, error)
+	ConsumeRecoveryCode(c context.Context, userID primitive.ObjectID, codeHash string) (bool, error)
+
+	GetReportingTree(c context.Context, userID primitive.ObjectID, depth int) (UserSummary, []OrgChartEntry, error)
+	GetReportingChain(c context.Context, userID primitive.ObjectID, depth int) (UserSummary, []OrgChartEntry, error)
+	GetReportingTreeAsOf(c context.Context, userID primitive.ObjectID, depth int, asOf time.Time) (UserSummary, []OrgChartEntry, error)
+	GetReportingChainAsOf(c context.Context, userID primitive.ObjectID, depth int, asOf time.Time) (UserSummary, []OrgChartEntry, error)
+	GetManagerHistory(c context.Context, userID primitive.ObjectID) ([]ReportingLine, map[primitive.ObjectID]UserSummary, error)
+	GetUserSummaries(c context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]UserSummary, error)
+	SetManager(c context.Context, userID primitive.ObjectID, managerID *primitive.ObjectID) error
+	ReconcileReportingLines(c context.Context, dryRun bool) (ReconcileReport, error)
 }
 
 type repositoryImpl struct {
-	collection *mongo.Collection
+	collection     *mongo.Collection
+	lineCollection *mongo.Collection
 }
 
+var indexesOnce sync.Once
+
 func NewUserRepository() UserRepository {
-	collection := db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_USER)
-	return &repositoryImpl{collection: collection}
+	r := &repositoryImpl{
+		collection:     db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_USER),
+		lineCollection: db.Client.Database(db.DATABASE_NAME).Collection(db.COLLECTION_REPORTING_LINE),
+	}
+
+	indexesOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := r.lineCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "userId", Value: 1}, {Key: "validFrom", Value: -1}}},
+			{Keys: bson.D{{Key: "organisationId", Value: 1}, {Key: "managerId", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create reporting line indexes: ", err)
+		}
+	})
+
+	return r
 }
 
+// CreateUser stores a user in the organisation of the context. Email addresses are unique
+// within an organisation. New users are given their manager with SetManager, which also opens
+// their first reporting line.
 func (r *repositoryImpl) CreateUser(c context.Context, user User) (User, error) {
+	organisationID, err := tenant.Require(c)
+	if err != nil {
+		return User{}, err
+	}
+	user.OrganisationID = organisationID
+	now := primitive.NewDateTimeFromTime(time.Now())
+	if user.CreatedAt == 0 {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+
 	filter := bson.M{"$or": []bson.M{{"email": user.Email}}}
 
 	var existingUser User
-	err := r.collection.FindOne(c, filter).Decode(&existingUser)
+	err = r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&existingUser)
 	if err == nil {
 		return User{}, errors.New("a user with this email already exists")
 	} else if err != mongo.ErrNoDocuments {
@@ -54,8 +113,15 @@ func (r *repositoryImpl) CreateUser(c context.Context, user User) (User, error)
 	return user, nil
 }
 
+// DeleteUser removes an account that was never handed out, such as one created for an invitation
+// that could not be set up. Accounts in use are deactivated instead.
+func (r *repositoryImpl) DeleteUser(c context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(c, tenant.Filter(c, bson.M{"_id": id}))
+	return err
+}
+
 func (r *repositoryImpl) GetAllUsers(c context.Context) ([]User, error) {
-	cursor, err := r.collection.Find(c, bson.D{})
+	cursor, err := r.collection.Find(c, tenant.Filter(c, bson.M{}))
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +139,7 @@ func (r *repositoryImpl) GetUserByID(c context.Context, id primitive.ObjectID) (
 	filter := bson.M{"_id": id}
 
 	var user User
-	err := r.collection.FindOne(c, filter).Decode(&user)
+	err := r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +151,7 @@ func (r *repositoryImpl) GetUserByEmail(c context.Context, email string) (*User,
 	filter := bson.M{"email": email}
 
 	var user User
-	err := r.collection.FindOne(c, filter).Decode(&user)
+	err := r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -93,38 +159,716 @@ func (r *repositoryImpl) GetUserByEmail(c context.Context, email string) (*User,
 	return &user, nil
 }
 
-func (r *repositoryImpl) AddReportee(c context.Context, userID primitive.ObjectID, reporteeID primitive.ObjectID) error {
+// GetUsers returns a page of the matching users in creation order, along with the total number
+// of matches.
+func (r *repositoryImpl) GetUsers(c context.Context, filter UserFilter, skip int64, limit int64) ([]User, int64, error) {
+	query := tenant.Filter(c, bson.M{})
+	if filter.ID != nil {
+		query["_id"] = *filter.ID
+	}
+	if filter.Email != "" {
+		query["email"] = filter.Email
+	}
+	if filter.ExternalID != "" {
+		query["externalId"] = filter.ExternalID
+	}
+	if filter.Active != nil {
+		query["deactivatedAt"] = bson.M{"$exists": !*filter.Active}
+	}
+
+	total, err := r.collection.CountDocuments(c, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip)
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(c, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(c)
+
+	users := []User{}
+	if err := cursor.All(c, &users); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// UpdateIdentity replaces the email, name and external ID of a user, as asserted by the
+// provisioning client.
+func (r *repositoryImpl) UpdateIdentity(c context.Context, userID primitive.ObjectID, email string, firstName string, lastName string, externalID string) error {
 	filter := bson.M{"_id": userID}
-	update := bson.M{"$addToSet": bson.M{"reportees": reporteeID}}
+	update := bson.M{
+		"$set": bson.M{
+			"email":     email,
+			"firstName": firstName,
+			"lastName":  lastName,
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+	if externalID != "" {
+		update["$set"].(bson.M)["externalId"] = externalID
+	} else {
+		update["$unset"] = bson.M{"externalId": ""}
+	}
 
-	_, err := r.collection.UpdateOne(c, filter, update)
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
 	return nil
 }
 
-func (r *repositoryImpl) RemoveReportee(c context.Context, userID primitive.ObjectID, reporteeID primitive.ObjectID) error {
+// SetActive deactivates or reactivates a user. It does not log a deactivated user out, which is
+// up to the caller.
+func (r *repositoryImpl) SetActive(c context.Context, userID primitive.ObjectID, active bool) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+
 	filter := bson.M{"_id": userID}
-	update := bson.M{"$pull": bson.M{"reportees": reporteeID}}
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": now},
+		"$unset": bson.M{"deactivatedAt": ""},
+	}
+	if !active {
+		filter["deactivatedAt"] = bson.M{"$exists": false}
+		update = bson.M{"$set": bson.M{"deactivatedAt": now, "updatedAt": now}}
+	}
 
-	_, err := r.collection.UpdateOne(c, filter, update)
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	return err
+}
+
+func (r *repositoryImpl) SetDirectoryDN(c context.Context, userID primitive.ObjectID, dn string) error {
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$set": bson.M{
+		"directoryDn": dn,
+		"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	return err
+}
+
+// UpdatePassword replaces the password hash of a user along with the hashes of the previous
+// passwords that may not be reused.
+func (r *repositoryImpl) UpdatePassword(c context.Context, userID primitive.ObjectID, password string, history []string) error {
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$set": bson.M{
+		"password":        password,
+		"passwordHistory": history,
+		"updatedAt":       primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
 	return nil
 }
 
-func (r *repositoryImpl) AddReportsTo(c context.Context, userID primitive.ObjectID, reportsToID primitive.ObjectID) error {
+// RehashPassword upgrades the hash of an unchanged password. Nothing happens when the password
+// was changed since oldHash was read.
+func (r *repositoryImpl) RehashPassword(c context.Context, userID primitive.ObjectID, oldHash string, newHash string) error {
+	filter := bson.M{"_id": userID, "password": oldHash}
+	update := bson.M{"$set": bson.M{"password": newHash}}
+
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	return err
+}
+
+// MarkEmailVerified flags the user's email as verified. The email is part of the filter so that
+// a link sent to a previous address cannot verify a new one.
+func (r *repositoryImpl) MarkEmailVerified(c context.Context, userID primitive.ObjectID, email string) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{"_id": userID, "email": email}
+	update := bson.M{"$set": bson.M{
+		"emailVerified":   true,
+		"emailVerifiedAt": now,
+		"updatedAt":       now,
+	}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) UpdateRoles(c context.Context, userID primitive.ObjectID, roles []string) (*User, error) {
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$set": bson.M{
+		"roles":     roles,
+		"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user User
+	err := r.collection.FindOneAndUpdate(c, tenant.Filter(c, filter), update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateProfile sets and clears profile fields of the user, see
+// ConvertUpdateProfileRequestToUpdate, and returns the updated user.
+func (r *repositoryImpl) UpdateProfile(c context.Context, userID primitive.ObjectID, set bson.M, unset bson.M) (*User, error) {
+	fields := bson.M{"updatedAt": primitive.NewDateTimeFromTime(time.Now())}
+	for field, value := range set {
+		fields[field] = value
+	}
+
+	update := bson.M{"$set": fields}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user User
+	err := r.collection.FindOneAndUpdate(c, tenant.Filter(c, bson.M{"_id": userID}), update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SetAvatar replaces the avatar of the user, or removes it when avatar is nil. It returns the
+// updated user and the avatar it had before, whose images are left for the caller to delete.
+func (r *repositoryImpl) SetAvatar(c context.Context, userID primitive.ObjectID, avatar *Avatar) (*User, *Avatar, error) {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	update := bson.M{"$set": bson.M{"avatar": avatar, "updatedAt": now}}
+	if avatar == nil {
+		update = bson.M{"$unset": bson.M{"avatar": ""}, "$set": bson.M{"updatedAt": now}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var previous User
+	err := r.collection.FindOneAndUpdate(c, tenant.Filter(c, bson.M{"_id": userID}), update, opts).Decode(&previous)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated := previous
+	updated.Avatar = avatar
+	updated.UpdatedAt = now
+
+	return &updated, previous.Avatar, nil
+}
+
+func (r *repositoryImpl) GetUserByOIDCSubject(c context.Context, subject string) (*User, error) {
+	filter := bson.M{"oidcSubject": subject}
+
+	var user User
+	err := r.collection.FindOne(c, tenant.Filter(c, filter)).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *repositoryImpl) SetOIDCSubject(c context.Context, userID primitive.ObjectID, subject string) error {
 	filter := bson.M{"_id": userID}
-	update := bson.M{"$set": bson.M{"reportsTo": reportsToID}}
+	update := bson.M{"$set": bson.M{
+		"oidcSubject": subject,
+		"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+	}}
 
-	_, err := r.collection.UpdateOne(c, filter, update)
+	_, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	return err
+}
+
+// UpdateMFA replaces the two-factor settings of the user, or removes them when mfa is nil.
+func (r *repositoryImpl) UpdateMFA(c context.Context, userID primitive.ObjectID, mfa *MFASettings) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	filter := bson.M{"_id": userID}
+
+	var update bson.M
+	if mfa == nil {
+		update = bson.M{"$unset": bson.M{"mfa": ""}, "$set": bson.M{"updatedAt": now}}
+	} else {
+		update = bson.M{"$set": bson.M{"mfa": mfa, "updatedAt": now}}
+	}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
 	return nil
 }
+
+// RecordMFAStep stores the time step of an accepted TOTP code. It reports false when that step,
+// or a later one, was already used, so that a code cannot be replayed.
+func (r *repositoryImpl) RecordMFAStep(c context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{
+		"_id":         userID,
+		"mfa.enabled": true,
+		"$or": []bson.M{
+			{"mfa.lastUsedStep": bson.M{"$lt": step}},
+			{"mfa.lastUsedStep": bson.M{"$exists": false}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"mfa.lastUsedStep": step}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ConsumeRecoveryCode removes a recovery code from the user. It reports false when the code is
+// not one of theirs.
+func (r *repositoryImpl) ConsumeRecoveryCode(c context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": userID, "mfa.enabled": true, "mfa.recoveryCodes": codeHash}
+	update := bson.M{"$pull": bson.M{"mfa.recoveryCodes": codeHash}}
+
+	result, err := r.collection.UpdateOne(c, tenant.Filter(c, filter), update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// GetReportingTree returns the user and everyone reporting to them, directly or not, down to
+// depth levels below the user.
+func (r *repositoryImpl) GetReportingTree(c context.Context, userID primitive.ObjectID, depth int) (UserSummary, []OrgChartEntry, error) {
+	return r.walkReportingLines(c, userID, "$_id", "_id", "reportsTo", depth)
+}
+
+// GetReportingChain returns the user and their managers, up to depth levels above the user.
+func (r *repositoryImpl) GetReportingChain(c context.Context, userID primitive.ObjectID, depth int) (UserSummary, []OrgChartEntry, error) {
+	return r.walkReportingLines(c, userID, "$reportsTo", "reportsTo", "_id", depth)
+}
+
+// walkReportingLines follows the reporting lines from the user with $graphLookup, which stops at
+// users it has seen before, so that a cycle left in the data cannot make the walk run forever.
+func (r *repositoryImpl) walkReportingLines(c context.Context, userID primitive.ObjectID, startWith string, connectFromField string, connectToField string, depth int) (UserSummary, []OrgChartEntry, error) {
+	summaryFields := bson.M{"email": 1, "firstName": 1, "lastName": 1, "avatar": 1}
+	relatedFields := bson.M{"related._id": 1, "related.email": 1, "related.firstName": 1, "related.lastName": 1, "related.avatar": 1, "related.reportsTo": 1, "related.depth": 1}
+	for key, value := range summaryFields {
+		relatedFields[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: tenant.Filter(c, bson.M{"_id": userID})}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    db.COLLECTION_USER,
+			"startWith":               startWith,
+			"connectFromField":        connectFromField,
+			"connectToField":          connectToField,
+			"as":                      "related",
+			"maxDepth":                depth - 1,
+			"depthField":              "depth",
+			"restrictSearchWithMatch": tenant.Filter(c, bson.M{}),
+		}}},
+		{{Key: "$project", Value: relatedFields}},
+	}
+
+	cursor, err := r.collection.Aggregate(c, pipeline)
+	if err != nil {
+		return UserSummary{}, nil, err
+	}
+	defer cursor.Close(c)
+
+	var results []struct {
+		UserSummary `bson:",inline"`
+		Related     []OrgChartEntry `bson:"related"`
+	}
+	if err := cursor.All(c, &results); err != nil {
+		return UserSummary{}, nil, err
+	}
+	if len(results) == 0 {
+		return UserSummary{}, nil, mongo.ErrNoDocuments
+	}
+
+	// $graphLookup counts depth from the first users it finds, one level away from the user.
+	for i := range results[0].Related {
+		results[0].Related[i].Depth++
+	}
+
+	return results[0].UserSummary, results[0].Related, nil
+}
+
+// GetReportingTreeAsOf returns the user and everyone reporting to them at the given time,
+// directly or not, down to depth levels below the user.
+func (r *repositoryImpl) GetReportingTreeAsOf(c context.Context, userID primitive.ObjectID, depth int, asOf time.Time) (UserSummary, []OrgChartEntry, error) {
+	return r.walkReportingHistory(c, userID, "userId", "managerId", depth, asOf)
+}
+
+// GetReportingChainAsOf returns the user and the managers above them at the given time, up to
+// depth levels above the user.
+func (r *repositoryImpl) GetReportingChainAsOf(c context.Context, userID primitive.ObjectID, depth int, asOf time.Time) (UserSummary, []OrgChartEntry, error) {
+	return r.walkReportingHistory(c, userID, "managerId", "userId", depth, asOf)
+}
+
+// walkReportingHistory is walkReportingLines over the reporting line records in effect at asOf
+// rather than the current reportsTo of each user.
+func (r *repositoryImpl) walkReportingHistory(c context.Context, userID primitive.ObjectID, connectFromField string, connectToField string, depth int, asOf time.Time) (UserSummary, []OrgChartEntry, error) {
+	at := primitive.NewDateTimeFromTime(asOf)
+	inEffect := tenant.Filter(c, bson.M{
+		"validFrom": bson.M{"$lte": at},
+		"$or":       bson.A{bson.M{"validTo": nil}, bson.M{"validTo": bson.M{"$gt": at}}},
+	})
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: tenant.Filter(c, bson.M{"_id": userID})}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    db.COLLECTION_REPORTING_LINE,
+			"startWith":               "$_id",
+			"connectFromField":        connectFromField,
+			"connectToField":          connectToField,
+			"as":                      "lines",
+			"maxDepth":                depth - 1,
+			"depthField":              "depth",
+			"restrictSearchWithMatch": inEffect,
+		}}},
+		{{Key: "$project", Value: bson.M{"email": 1, "firstName": 1, "lastName": 1, "avatar": 1, "lines": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(c, pipeline)
+	if err != nil {
+		return UserSummary{}, nil, err
+	}
+	defer cursor.Close(c)
+
+	var results []struct {
+		UserSummary `bson:",inline"`
+		Lines       []struct {
+			ReportingLine `bson:",inline"`
+			Depth         int `bson:"depth"`
+		} `bson:"lines"`
+	}
+	if err := cursor.All(c, &results); err != nil {
+		return UserSummary{}, nil, err
+	}
+	if len(results) == 0 {
+		return UserSummary{}, nil, mongo.ErrNoDocuments
+	}
+
+	// Walking down, each line leads to the user who reported; walking up, to their manager.
+	managerOf := map[primitive.ObjectID]primitive.ObjectID{}
+	found := map[primitive.ObjectID]int{}
+	for _, line := range results[0].Lines {
+		managerOf[line.UserID] = line.ManagerID
+		if connectFromField == "userId" {
+			found[line.UserID] = line.Depth + 1
+		} else {
+			found[line.ManagerID] = line.Depth + 1
+		}
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	summaries, err := r.GetUserSummaries(c, ids)
+	if err != nil {
+		return UserSummary{}, nil, err
+	}
+
+	entries := []OrgChartEntry{}
+	for id, depth := range found {
+		summary, ok := summaries[id]
+		if !ok || id == userID {
+			continue
+		}
+		entry := OrgChartEntry{UserSummary: summary, Depth: depth}
+		if managerID, ok := managerOf[id]; ok {
+			entry.ReportsTo = &managerID
+		}
+		entries = append(entries, entry)
+	}
+
+	return results[0].UserSummary, entries, nil
+}
+
+// GetManagerHistory returns the reporting lines of the user, the current one first, with the
+// managers they name.
+func (r *repositoryImpl) GetManagerHistory(c context.Context, userID primitive.ObjectID) ([]ReportingLine, map[primitive.ObjectID]UserSummary, error) {
+	if _, err := r.GetUserByID(c, userID); err != nil {
+		return nil, nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "validFrom", Value: -1}})
+	cursor, err := r.lineCollection.Find(c, tenant.Filter(c, bson.M{"userId": userID}), findOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(c)
+
+	lines := []ReportingLine{}
+	if err := cursor.All(c, &lines); err != nil {
+		return nil, nil, err
+	}
+
+	managerIDs := make([]primitive.ObjectID, len(lines))
+	for i, line := range lines {
+		managerIDs[i] = line.ManagerID
+	}
+	managers, err := r.GetUserSummaries(c, managerIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lines, managers, nil
+}
+
+// GetUserSummaries returns the users with the given IDs by ID. Users that no longer exist are
+// missing.
+func (r *repositoryImpl) GetUserSummaries(c context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]UserSummary, error) {
+	summaries := map[primitive.ObjectID]UserSummary{}
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	projection := options.Find().SetProjection(bson.M{"email": 1, "firstName": 1, "lastName": 1, "avatar": 1})
+	cursor, err := r.collection.Find(c, tenant.Filter(c, bson.M{"_id": bson.M{"$in": ids}}), projection)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	var users []UserSummary
+	if err := cursor.All(c, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		summaries[user.ID] = user
+	}
+
+	return summaries, nil
+}
+
+// SetManager makes the user report to managerID, or to nobody when managerID is nil. Both have
+// to belong to the organisation of the context. The user's reportsTo and the reportees of the
+// old and new manager are updated in one transaction, which needs MongoDB to run as a replica
+// set.
+func (r *repositoryImpl) SetManager(c context.Context, userID primitive.ObjectID, managerID *primitive.ObjectID) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(c)
+
+	_, err = session.WithTransaction(c, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, r.setManager(sc, userID, managerID)
+	})
+	return err
+}
+
+func (r *repositoryImpl) setManager(sc mongo.SessionContext, userID primitive.ObjectID, managerID *primitive.ObjectID) error {
+	account, err := r.GetUserByID(sc, userID)
+	if err != nil {
+		return err
+	}
+
+	if managerID != nil {
+		if *managerID == userID {
+			return ErrSelfManager
+		}
+		if err := r.checkChain(sc, userID, *managerID); err != nil {
+			return err
+		}
+	}
+
+	// Pulling the user from every list but the new manager's also drops entries left behind
+	// by earlier inconsistencies.
+	now := primitive.NewDateTimeFromTime(time.Now())
+	pullFilter := tenant.Filter(sc, bson.M{"reportees": userID})
+	if managerID != nil {
+		pullFilter["_id"] = bson.M{"$ne": *managerID}
+	}
+	if _, err := r.collection.UpdateMany(sc, pullFilter, bson.M{"$pull": bson.M{"reportees": userID}, "$set": bson.M{"updatedAt": now}}); err != nil {
+		return err
+	}
+
+	if err := r.recordReportingLine(sc, *account, managerID); err != nil {
+		return err
+	}
+
+	if managerID == nil {
+		_, err := r.collection.UpdateOne(sc, tenant.Filter(sc, bson.M{"_id": userID}), bson.M{"$unset": bson.M{"reportsTo": ""}, "$set": bson.M{"updatedAt": now}})
+		return err
+	}
+
+	if _, err := r.collection.UpdateOne(sc, tenant.Filter(sc, bson.M{"_id": userID}), bson.M{"$set": bson.M{"reportsTo": *managerID, "updatedAt": now}}); err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateOne(sc, tenant.Filter(sc, bson.M{"_id": *managerID}), bson.M{"$addToSet": bson.M{"reportees": userID}, "$set": bson.M{"updatedAt": now}})
+	return err
+}
+
+// recordReportingLine ends the current reporting line of the user and starts one to managerID,
+// unless the user already reports to managerID.
+func (r *repositoryImpl) recordReportingLine(c context.Context, account User, managerID *primitive.ObjectID) error {
+	if (account.ReportsTo == nil && managerID == nil) || (account.ReportsTo != nil && managerID != nil && *account.ReportsTo == *managerID) {
+		return nil
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	openFilter := tenant.Filter(c, bson.M{"userId": account.ID, "validTo": nil})
+	if _, err := r.lineCollection.UpdateMany(c, openFilter, bson.M{"$set": bson.M{"validTo": now}}); err != nil {
+		return err
+	}
+
+	if managerID == nil {
+		return nil
+	}
+
+	_, err := r.lineCollection.InsertOne(c, ReportingLine{
+		ID:             primitive.NewObjectID(),
+		OrganisationID: account.OrganisationID,
+		UserID:         account.ID,
+		ManagerID:      *managerID,
+		ValidFrom:      now,
+	})
+	return err
+}
+
+// checkChain walks up from the manager to the top of the organisation and fails when it passes
+// the user, as the new reporting line would then close a cycle.
+func (r *repositoryImpl) checkChain(c context.Context, userID primitive.ObjectID, managerID primitive.ObjectID) error {
+	visited := map[primitive.ObjectID]bool{}
+	current := &managerID
+
+	for current != nil && !visited[*current] {
+		if *current == userID {
+			return ErrReportingCycle
+		}
+		visited[*current] = true
+
+		var link User
+		err := r.collection.FindOne(c, tenant.Filter(c, bson.M{"_id": *current}), options.FindOne().SetProjection(bson.M{"reportsTo": 1})).Decode(&link)
+		if err == mongo.ErrNoDocuments {
+			if *current == managerID {
+				return ErrManagerNotFound
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		current = link.ReportsTo
+	}
+
+	return nil
+}
+
+// ReconcileReportingLines repairs reporting lines written before SetManager kept both sides in
+// step, in every organisation, and the reporting line records that do not match them. See
+// planReconciliation and planLineFixes for the rules; with dryRun set, nothing is changed.
+func (r *repositoryImpl) ReconcileReportingLines(c context.Context, dryRun bool) (ReconcileReport, error) {
+	projection := options.Find().SetProjection(bson.M{"organisationId": 1, "reportsTo": 1, "reportees": 1, "createdAt": 1})
+	cursor, err := r.collection.Find(c, bson.M{}, projection)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	defer cursor.Close(c)
+
+	var users []User
+	if err := cursor.All(c, &users); err != nil {
+		return ReconcileReport{}, err
+	}
+
+	lineCursor, err := r.lineCollection.Find(c, bson.M{"validTo": nil})
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	defer lineCursor.Close(c)
+
+	var openLines []ReportingLine
+	if err := lineCursor.All(c, &openLines); err != nil {
+		return ReconcileReport{}, err
+	}
+
+	historyIDs, err := r.lineCollection.Distinct(c, "userId", bson.M{})
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	withHistory := map[primitive.ObjectID]bool{}
+	for _, id := range historyIDs {
+		if id, ok := id.(primitive.ObjectID); ok {
+			withHistory[id] = true
+		}
+	}
+
+	report, expected := planReconciliation(users)
+	closed, opened := planLineFixes(users, expected, openLines, withHistory)
+	report.LineFixes = lineFixes(closed, opened)
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	writes := []mongo.WriteModel{}
+	for _, fix := range report.ClearedManagers {
+		id, _ := primitive.ObjectIDFromHex(fix.UserID)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$unset": bson.M{"reportsTo": ""}, "$set": bson.M{"updatedAt": now}}))
+	}
+	for _, fix := range report.ReporteeFixes {
+		id, _ := primitive.ObjectIDFromHex(fix.ManagerID)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"reportees": expected[id], "updatedAt": now}}))
+	}
+
+	if len(writes) > 0 {
+		if _, err := r.collection.BulkWrite(c, writes); err != nil {
+			return ReconcileReport{}, err
+		}
+	}
+
+	lineWrites := []mongo.WriteModel{}
+	for _, line := range closed {
+		lineWrites = append(lineWrites, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": line.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"validTo": now}}))
+	}
+	for _, line := range opened {
+		if line.ValidFrom == 0 {
+			line.ValidFrom = now
+		}
+		lineWrites = append(lineWrites, mongo.NewInsertOneModel().SetDocument(line))
+	}
+
+	if len(lineWrites) > 0 {
+		if _, err := r.lineCollection.BulkWrite(c, lineWrites); err != nil {
+			return ReconcileReport{}, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"net/http"
+	"one-to-one/internal/api"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// @Summary List sessions
+// @Description List the devices the current user is logged in on, most recently used first
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {array} SessionResponse "Sessions retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/sessions [get]
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	sessions, err := h.AuthRepo.GetSessions(c.Request.Context(), userID)
+	if err != nil {
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = ConvertToSessionResponse(session, c.GetString("sessionId"))
+	}
+
+	api.Success(c, http.StatusOK, "Retrieved sessions successfully", response)
+}
+
+// @Summary Revoke a session
+// @Description Log the current user out of one of their sessions. Its tokens stop working immediately.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Session revoked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid session ID"
+// @Failure 404 {object} map[string]interface{} "Session not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /user/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		api.Error(c, http.StatusUnauthorized, "Invalid token", nil)
+		return
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		api.Error(c, http.StatusBadRequest, "Invalid session ID", nil)
+		return
+	}
+
+	if err := h.AuthRepo.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			api.Error(c, http.StatusNotFound, "Session not found", nil)
+			return
+		}
+		api.Error(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	api.Success(c, http.StatusOK, "Revoked session successfully", nil)
+}
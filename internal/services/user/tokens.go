@@ -0,0 +1,191 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/config"
+	"one-to-one/internal/middleware"
+	"one-to-one/internal/services/auth"
+	"one-to-one/internal/tenant"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrUserDeactivated     = errors.New("account has been deactivated")
+)
+
+// TokenOptions describes how and from where the user logged in.
+type TokenOptions struct {
+	// MFA is set when the login passed the TOTP second factor.
+	MFA bool
+
+	// UserAgent and IP describe the device of the new session.
+	UserAgent string
+	IP        string
+}
+
+// NewTokenOptions returns the options for a login made with the request.
+func NewTokenOptions(c *gin.Context, mfa bool) TokenOptions {
+	return TokenOptions{MFA: mfa, UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// IssueTokens starts a new session for the user and mints its access token and first refresh
+// token. The session and the refresh token family share their ID.
+func IssueTokens(c context.Context, authRepo auth.AuthRepository, user User, opts TokenOptions) (TokenResponse, error) {
+	if !user.Active() {
+		return TokenResponse{}, ErrUserDeactivated
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	session := auth.Session{
+		ID:             primitive.NewObjectID(),
+		OrganisationID: user.OrganisationID,
+		UserID:         user.ID,
+		UserAgent:      opts.UserAgent,
+		IP:             opts.IP,
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		ExpiresAt:      refreshTokenExpiry(),
+	}
+	if err := authRepo.CreateSession(c, session); err != nil {
+		return TokenResponse{}, err
+	}
+
+	return issueTokens(c, authRepo, user, session.ID, opts)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new access and refresh token pair.
+// A refresh token can only be exchanged once. Presenting it a second time revokes every
+// token of its family, since it means that either the client or an attacker holds a copy.
+// The refresh request does not have to name an organisation, so the user is looked up in the
+// one the token was issued for.
+func RotateRefreshToken(c context.Context, authRepo auth.AuthRepository, userRepo UserRepository, refreshToken string, ip string) (TokenResponse, error) {
+	stored, err := authRepo.GetRefreshTokenByHash(c, auth.HashToken(refreshToken))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return TokenResponse{}, ErrInvalidRefreshToken
+		}
+		return TokenResponse{}, err
+	}
+
+	if stored.RevokedAt != nil {
+		return TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	if stored.UsedAt != nil {
+		if err := authRepo.RevokeRefreshTokenFamily(c, stored.FamilyID); err != nil {
+			return TokenResponse{}, err
+		}
+		return TokenResponse{}, ErrRefreshTokenReused
+	}
+
+	if stored.ExpiresAt.Time().Before(time.Now()) {
+		return TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	// Tokens issued before they recorded their organisation rely on the request's instead.
+	if !stored.OrganisationID.IsZero() {
+		c = tenant.WithOrganisation(c, stored.OrganisationID)
+	}
+
+	user, err := userRepo.GetUserByID(c, stored.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return TokenResponse{}, ErrInvalidRefreshToken
+		}
+		return TokenResponse{}, err
+	}
+	if !user.Active() {
+		return TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	// Two concurrent requests may both get past the checks above, only one of them wins here.
+	marked, err := authRepo.MarkRefreshTokenUsed(c, stored.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if !marked {
+		if err := authRepo.RevokeRefreshTokenFamily(c, stored.FamilyID); err != nil {
+			return TokenResponse{}, err
+		}
+		return TokenResponse{}, ErrRefreshTokenReused
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	err = authRepo.RefreshSession(c, auth.Session{
+		ID:             stored.FamilyID,
+		OrganisationID: user.OrganisationID,
+		UserID:         user.ID,
+		IP:             ip,
+		CreatedAt:      stored.CreatedAt,
+		LastSeenAt:     now,
+		ExpiresAt:      refreshTokenExpiry(),
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return issueTokens(c, authRepo, *user, stored.FamilyID, TokenOptions{MFA: stored.MFA})
+}
+
+func refreshTokenExpiry() primitive.DateTime {
+	return primitive.NewDateTimeFromTime(time.Now().AddDate(0, 0, config.AppConfig().Auth.TokenExpire))
+}
+
+func issueTokens(c context.Context, authRepo auth.AuthRepository, user User, familyID primitive.ObjectID, opts TokenOptions) (TokenResponse, error) {
+	accessToken, err := middleware.GenerateJWTToken(middleware.TokenSubject{
+		Email:          user.Email,
+		UserID:         user.ID.Hex(),
+		OrganisationID: user.OrganisationID.Hex(),
+		EmailVerified:  user.EmailVerified,
+		Roles:          user.EffectiveRoles(),
+		MFA:            opts.MFA,
+		SessionID:      familyID.Hex(),
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	refreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	now := time.Now()
+	err = authRepo.CreateRefreshToken(c, auth.RefreshToken{
+		ID:             primitive.NewObjectID(),
+		OrganisationID: user.OrganisationID,
+		UserID:         user.ID,
+		FamilyID:       familyID,
+		TokenHash:      auth.HashToken(refreshToken),
+		MFA:            opts.MFA,
+		ExpiresAt:      refreshTokenExpiry(),
+		CreatedAt:      primitive.NewDateTimeFromTime(now),
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    config.AppConfig().Auth.ShortTokenExpire * 60,
+	}, nil
+}
+
+// ClientLink builds a link to a page of the client that is handed a token. The link names the
+// organisation, so that the client can send it along with the token.
+func ClientLink(path string, token string, organisationID primitive.ObjectID) string {
+	link := config.AppConfig().App.ClientURL + path + "?token=" + url.QueryEscape(token)
+	if !organisationID.IsZero() {
+		link += "&" + middleware.TenantQueryParam + "=" + organisationID.Hex()
+	}
+	return link
+}
@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"one-to-one/internal/config"
+	"one-to-one/internal/services/auth"
+)
+
+// tokenUserRepo serves a single user from memory. Methods the token flow does not use are left
+// to the embedded nil interface.
+type tokenUserRepo struct {
+	UserRepository
+	user *User
+}
+
+func (r *tokenUserRepo) GetUserByID(c context.Context, id primitive.ObjectID) (*User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	account := *r.user
+	return &account, nil
+}
+
+// tokenAuthRepo keeps refresh tokens and sessions in memory with the same single-use and family
+// rules as the MongoDB repository.
+type tokenAuthRepo struct {
+	auth.AuthRepository
+	tokens   []auth.RefreshToken
+	sessions map[primitive.ObjectID]auth.Session
+
+	// beforeMark runs when a token is about to be marked used, to let another request in first.
+	beforeMark func()
+}
+
+func (r *tokenAuthRepo) CreateSession(c context.Context, session auth.Session) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *tokenAuthRepo) RefreshSession(c context.Context, session auth.Session) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *tokenAuthRepo) CreateRefreshToken(c context.Context, token auth.RefreshToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *tokenAuthRepo) GetRefreshTokenByHash(c context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *tokenAuthRepo) MarkRefreshTokenUsed(c context.Context, id primitive.ObjectID) (bool, error) {
+	if hook := r.beforeMark; hook != nil {
+		r.beforeMark = nil
+		hook()
+	}
+
+	for i := range r.tokens {
+		token := &r.tokens[i]
+		if token.ID == id && token.UsedAt == nil && token.RevokedAt == nil {
+			now := primitive.NewDateTimeFromTime(time.Now())
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *tokenAuthRepo) RevokeRefreshTokenFamily(c context.Context, familyID primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	for i := range r.tokens {
+		if r.tokens[i].FamilyID == familyID && r.tokens[i].RevokedAt == nil {
+			r.tokens[i].RevokedAt = &now
+		}
+	}
+	if session, ok := r.sessions[familyID]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &now
+		r.sessions[familyID] = session
+	}
+	return nil
+}
+
+// familyRevoked reports whether every refresh token of the family and its session are revoked.
+func (r *tokenAuthRepo) familyRevoked(familyID primitive.ObjectID) bool {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			return false
+		}
+	}
+	return r.sessions[familyID].RevokedAt != nil
+}
+
+type tokenFixture struct {
+	users    *tokenUserRepo
+	authRepo *tokenAuthRepo
+}
+
+// newTokenFixture logs a user in and returns the first refresh token of the session.
+func newTokenFixture(t *testing.T) (*tokenFixture, TokenResponse) {
+	t.Helper()
+
+	config.AppConfig().App.Environment = "local"
+	config.AppConfig().Auth.JWTSecret = "tokens-test-secret"
+	config.AppConfig().Auth.ShortTokenExpire = 15
+	config.AppConfig().Auth.TokenExpire = 7
+
+	account := User{ID: primitive.NewObjectID(), Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"}
+	f := &tokenFixture{
+		users:    &tokenUserRepo{user: &account},
+		authRepo: &tokenAuthRepo{sessions: map[primitive.ObjectID]auth.Session{}},
+	}
+
+	tokens, err := IssueTokens(context.Background(), f.authRepo, account, TokenOptions{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	return f, tokens
+}
+
+func (f *tokenFixture) rotate(refreshToken string) (TokenResponse, error) {
+	return RotateRefreshToken(context.Background(), f.authRepo, f.users, refreshToken, "192.0.2.1")
+}
+
+func (f *tokenFixture) familyOf(t *testing.T, refreshToken string) primitive.ObjectID {
+	t.Helper()
+
+	stored, err := f.authRepo.GetRefreshTokenByHash(context.Background(), auth.HashToken(refreshToken))
+	if err != nil {
+		t.Fatalf("refresh token not stored: %v", err)
+	}
+	return stored.FamilyID
+}
+
+func TestRotateRefreshTokenIssuesNextTokenOfFamily(t *testing.T) {
+	f, first := newTokenFixture(t)
+
+	second, err := f.rotate(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if second.Token == "" || second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("rotation returned %+v", second)
+	}
+
+	family := f.familyOf(t, first.RefreshToken)
+	if f.familyOf(t, second.RefreshToken) != family {
+		t.Errorf("the new refresh token left the family of the old one")
+	}
+	if f.authRepo.sessions[family].IP != "192.0.2.1" {
+		t.Errorf("the session was not refreshed")
+	}
+
+	if _, err := f.rotate(second.RefreshToken); err != nil {
+		t.Errorf("rotating the new refresh token: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesFamily(t *testing.T) {
+	f, first := newTokenFixture(t)
+	second, err := f.rotate(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	if _, err := f.rotate(first.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("second use answered %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if !f.authRepo.familyRevoked(f.familyOf(t, first.RefreshToken)) {
+		t.Errorf("reuse did not revoke the family")
+	}
+
+	// The token handed out by the legitimate rotation is revoked along with the rest.
+	if _, err := f.rotate(second.RefreshToken); err != ErrInvalidRefreshToken {
+		t.Errorf("rotating a revoked token answered %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRotateRefreshTokenConcurrentUseRevokesFamily(t *testing.T) {
+	f, first := newTokenFixture(t)
+
+	// Both requests read the unused token, the other one marks it used first.
+	var racing error
+	f.authRepo.beforeMark = func() {
+		_, racing = f.rotate(first.RefreshToken)
+	}
+
+	if _, err := f.rotate(first.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("losing request answered %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if racing != nil {
+		t.Fatalf("winning request: %v", racing)
+	}
+	if !f.authRepo.familyRevoked(f.familyOf(t, first.RefreshToken)) {
+		t.Errorf("concurrent use did not revoke the family")
+	}
+}
+
+func TestRotateRefreshTokenRefusesDeactivatedUser(t *testing.T) {
+	f, first := newTokenFixture(t)
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	f.users.user.DeactivatedAt = &now
+
+	if _, err := f.rotate(first.RefreshToken); err != ErrInvalidRefreshToken {
+		t.Fatalf("deactivated user answered %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if len(f.authRepo.tokens) != 1 || f.authRepo.tokens[0].UsedAt != nil {
+		t.Errorf("a refresh token was used or issued for a deactivated user")
+	}
+}
+
+func TestRotateRefreshTokenRejectsUnknownAndExpiredTokens(t *testing.T) {
+	f, first := newTokenFixture(t)
+
+	if _, err := f.rotate("not-a-token"); err != ErrInvalidRefreshToken {
+		t.Errorf("unknown token answered %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	f.authRepo.tokens[0].ExpiresAt = primitive.NewDateTimeFromTime(time.Now().Add(-time.Minute))
+	if _, err := f.rotate(first.RefreshToken); err != ErrInvalidRefreshToken {
+		t.Errorf("expired token answered %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
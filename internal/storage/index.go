@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"one-to-one/internal/config"
+)
+
+// LocalURLPath is where the API serves the files of the local backend.
+const LocalURLPath = "/uploads"
+
+// ErrNotFound is returned when no object is stored under the key.
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps blobs, such as avatars, under keys made of slash-separated segments.
+type Storage interface {
+	Put(c context.Context, key string, contentType string, data []byte) error
+	Delete(c context.Context, key string) error
+	// URL returns the address the object can be downloaded from.
+	URL(key string) string
+}
+
+var Client Storage
+
+// Init picks the storage backend from the configuration. Files are kept on the local disk
+// unless an S3-compatible bucket is configured.
+func Init() {
+	cfg := config.AppConfig().Storage
+
+	if cfg.Backend == "s3" {
+		Client = NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3PathStyle, cfg.PublicURL)
+		return
+	}
+
+	if cfg.Backend != "local" {
+		log.Println("Unknown storage backend " + cfg.Backend + ", files will be stored on the local disk.")
+	}
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = LocalURLPath
+	}
+	Client = NewLocalStorage(cfg.LocalDir, publicURL)
+}
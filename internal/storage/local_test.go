@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStorage(dir, "/uploads/")
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "avatars/u1/small.png", "image/png", []byte("small")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	filename := filepath.Join(dir, "avatars", "u1", "small.png")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading the stored file: %v", err)
+	}
+	if string(data) != "small" {
+		t.Errorf("stored %q, want %q", data, "small")
+	}
+
+	if err := store.Put(ctx, "avatars/u1/small.png", "image/png", []byte("replaced")); err != nil {
+		t.Fatalf("Put over an existing file: %v", err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "replaced" {
+		t.Errorf("stored %q after replacing, want %q", data, "replaced")
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "avatars", "u1", ".upload-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	if url := store.URL("avatars/u1/small.png"); url != "/uploads/avatars/u1/small.png" {
+		t.Errorf("URL = %q", url)
+	}
+
+	if err := store.Delete(ctx, "avatars/u1/small.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "avatars/u1/small.png"); err != ErrNotFound {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "uploads")
+	store := NewLocalStorage(dir, "/uploads")
+	ctx := context.Background()
+
+	keys := []string{
+		"",
+		".",
+		"..",
+		"../escape.png",
+		"avatars/../../escape.png",
+		"avatars/./small.png",
+		"avatars//small.png",
+		"/escape.png",
+		"avatars/",
+	}
+	for _, key := range keys {
+		if err := store.Put(ctx, key, "image/png", []byte("x")); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+		if err := store.Delete(ctx, key); err == nil || err == ErrNotFound {
+			t.Errorf("Delete(%q) = %v, want an invalid key error", key, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "escape.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a file was written outside the storage directory: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNoTenant is returned when data is written without an organisation in the context.
+var ErrNoTenant = errors.New("no organisation was resolved for this request")
+
+// Field is the field holding the organisation of tenant-scoped documents.
+const Field = "organisationId"
+
+type contextKey struct{}
+
+// WithOrganisation returns a context scoped to the organisation. The tenant middleware scopes
+// every request, jobs that work on one organisation have to do it themselves.
+func WithOrganisation(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// OrganisationID returns the organisation the context is scoped to.
+func OrganisationID(ctx context.Context) (primitive.ObjectID, bool) {
+	id, ok := ctx.Value(contextKey{}).(primitive.ObjectID)
+	return id, ok && !id.IsZero()
+}
+
+// Require returns the organisation the context is scoped to, or ErrNoTenant.
+func Require(ctx context.Context) (primitive.ObjectID, error) {
+	id, ok := OrganisationID(ctx)
+	if !ok {
+		return primitive.NilObjectID, ErrNoTenant
+	}
+	return id, nil
+}
+
+// Filter restricts a query to the organisation of the context. Without one, the query matches
+// nothing, so a missing scope can never leak another organisation's data.
+func Filter(ctx context.Context, filter bson.M) bson.M {
+	id, _ := OrganisationID(ctx)
+
+	scoped := bson.M{Field: id}
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	return scoped
+}